@@ -0,0 +1,103 @@
+// Package clientflags OnlineConf connection flags shared by the commands
+package clientflags
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	client "onlineconf-yaml/onlineconf"
+)
+
+// Flags OnlineConf connection, authentication, logging and secret masking flags
+type Flags struct {
+	OnlineConfURL   *string
+	MainNodeName    *string
+	headersFilepath *string
+	basicAuthKey    *string
+	username        *string
+	password        *string
+	token           *string
+	netrc           *bool
+	logLevel        *string
+	secretPatterns  *string
+	timeout         *time.Duration
+	retries         *int
+	caFilepath      *string
+	certFilepath    *string
+	keyFilepath     *string
+	insecure        *bool
+}
+
+// Register define the flags in the flag set
+func Register(flags *flag.FlagSet) *Flags {
+	return &Flags{
+		OnlineConfURL:   flags.String("onlineConfURL", "https://onlineconf.local", "OnlineConf URL name"),
+		MainNodeName:    flags.String("mainNodeName", "", "OnlineConf main node name"),
+		headersFilepath: flags.String("headersFilepath", "", "file with raw browser headers"),
+		basicAuthKey:    flags.String("basicAuthKey", "", "Basic autorization key (docker only)"),
+		username:        flags.String("username", "", "OnlineConf username, password is taken from ONLINECONF_PASSWORD if not set"),
+		password:        flags.String("password", "", "OnlineConf password"),
+		token:           flags.String("token", "", "OnlineConf bearer token"),
+		netrc:           flags.Bool("netrc", false, "Take credentials of the OnlineConf host from $NETRC or ~/.netrc"),
+		logLevel:        flags.String("logLevel", "info", "log level: error, info or debug, request params and responses are logged in debug"),
+		secretPatterns:  flags.String("secretPatterns", strings.Join(client.DefaultSecretPatterns, ","), "comma separated patterns of secret node names whose values are masked in the log and output"),
+		timeout:         flags.Duration("timeout", client.DefaultTimeout, "OnlineConf request timeout"),
+		retries:         flags.Int("retries", client.DefaultRetries, "number of retries of GET and DELETE requests"),
+		caFilepath:      flags.String("caFilepath", "", "file with trusted CA certificates"),
+		certFilepath:    flags.String("certFilepath", "", "file with client certificate"),
+		keyFilepath:     flags.String("keyFilepath", "", "file with client certificate key"),
+		insecure:        flags.Bool("insecure", false, "Skip OnlineConf certificate verification"),
+	}
+}
+
+// NewRedactor redactor of the secret patterns, keys of the secret nodes of the config are added by the command
+func (f *Flags) NewRedactor() (*client.Redactor, error) {
+	return client.NewRedactor(strings.Split(*f.secretPatterns, ","))
+}
+
+// NewClient client of the main node
+func (f *Flags) NewClient(redactor *client.Redactor) (*client.OnlineConfClient, error) {
+	tlsConfig, err := client.NewTLSConfig(client.TLSOptions{
+		CAFilepath:   *f.caFilepath,
+		CertFilepath: *f.certFilepath,
+		KeyFilepath:  *f.keyFilepath,
+		Insecure:     *f.insecure,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	level, err := client.ParseLogLevel(*f.logLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	onlineConfURL := regexp.MustCompile(`/+$`).ReplaceAllString(*f.OnlineConfURL, "")
+	authOptions := client.AuthOptions{
+		Token:        *f.token,
+		Username:     *f.username,
+		Password:     *f.password,
+		BasicAuthKey: *f.basicAuthKey,
+	}
+	if *f.netrc {
+		authOptions.NetrcFilepath = client.DefaultNetrcFilepath()
+	}
+	auth, err := client.NewAuth(authOptions, onlineConfURL)
+	if err != nil {
+		return nil, err
+	}
+	return client.NewOnlineConfClient(
+		fmt.Sprintf("%s/%s/%s", onlineConfURL, client.URLPrefix, *f.MainNodeName),
+		*f.headersFilepath,
+		"",
+		client.WithTimeout(*f.timeout),
+		client.WithRetries(*f.retries, client.DefaultRetryBackoff),
+		client.WithTLSConfig(tlsConfig),
+		client.WithLogLevel(level),
+		client.WithRedactor(redactor),
+		client.WithAuth(auth),
+	)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"log"
+
+	"onlineconf-yaml/cmd/internal/clientflags"
+)
+
+/*
+go run cmd/onlineconf2yml/main.go -onlineConfURL https://onlineconf.local -headersFilepath ./headers.txt -mainNodeName importConfig -exportConfigFilepath ./importConfig.yml
+*/
+func main() {
+
+	clientFlags := clientflags.Register(flag.CommandLine)
+	exportConfigFilepath := flag.String("exportConfigFilepath", "", "export config filepath, stdout if empty")
+
+	flag.Parse()
+
+	if *clientFlags.MainNodeName == "" {
+		log.Fatal(fmt.Errorf("main node name is empty"))
+	}
+
+	redactor, err := clientFlags.NewRedactor()
+	if err != nil {
+		log.Fatal(err)
+	}
+	onlineConfClient, err := clientFlags.NewClient(redactor)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	content, err := onlineConfClient.ExportYML(context.Background(), "")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *exportConfigFilepath == "" {
+		_, err = os.Stdout.Write(content)
+	} else {
+		err = os.WriteFile(*exportConfigFilepath, content, 0644)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -3,7 +3,7 @@ package main
 import (
 	"flag"
 	"fmt"
-	"reflect"
+	"strings"
 
 	"log"
 
@@ -17,13 +17,16 @@ go run cmd/yml2cdb/main.go -ymlConfigFilepath ./importConfig.yml -cdbConfigFilep
 
 func main() {
 
-	ymlConfigFilepath := flag.String("ymlConfigFilepath", "", "yml input config filepath")
+	var ymlConfigFilepaths filepathsFlag
+	flag.Var(&ymlConfigFilepaths, "ymlConfigFilepath", "yml input config filepath, repeat to merge overlay layers in order, e.g. base and production")
 	cdbConfigFilepath := flag.String("cdbConfigFilepath", "", "cdb output config filepath")
 	showParsedConfig := flag.Bool("showParsedConfig", false, "Show parsed config")
+	documents := flag.String("documents", string(parser.DocumentsMerge), "how documents of multi-document yaml are combined: merge - later documents override keys of earlier ones, separate - every document is written to the node named by its index")
+	interpolateEnv := flag.Bool("interpolateEnv", false, "Replace ${VAR} and ${VAR:-default} in values with environment variables, $$ is the dollar sign, values tagged as !env are always replaced")
 
 	flag.Parse()
 
-	if *ymlConfigFilepath == "" {
+	if len(ymlConfigFilepaths) == 0 {
 		log.Fatal(fmt.Errorf("input filepath config is empty"))
 	}
 
@@ -31,17 +34,23 @@ func main() {
 		log.Fatal(fmt.Errorf("output filepath config is empty"))
 	}
 
-	data, err := parser.GetYMLConfig(*ymlConfigFilepath)
+	documentsMode, err := parser.ParseDocumentsMode(*documents)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	obj := reflect.ValueOf(&data)
-	src := parser.WalkByYML(obj, "", true)
+	layers, err := parser.GetYMLLayers(ymlConfigFilepaths, documentsMode, *interpolateEnv)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	src := parser.WalkByYMLNode(layers.Node, "", true)
 
 	params := make([]cdb.WriteItem, len(src))
 	for k, v := range src {
-		if *showParsedConfig {
+		if *showParsedConfig && len(ymlConfigFilepaths) > 1 {
+			log.Printf("%-50s (%-30s) : %v (from %s)\n", k, v.Type, v.Value, layers.Origin(k))
+		} else if *showParsedConfig {
 			log.Printf("%-50s (%-30s) : %v\n", k, v.Type, v.Value)
 		}
 
@@ -57,3 +66,15 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// filepathsFlag repeated filepath flag
+type filepathsFlag []string
+
+func (filepaths *filepathsFlag) String() string {
+	return strings.Join(*filepaths, ",")
+}
+
+func (filepaths *filepathsFlag) Set(value string) error {
+	*filepaths = append(*filepaths, value)
+	return nil
+}
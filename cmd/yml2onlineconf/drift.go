@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"onlineconf-yaml/cmd/internal/clientflags"
+	client "onlineconf-yaml/onlineconf"
+	"onlineconf-yaml/yml/parser"
+)
+
+// DriftExitCode exit code of the drift command when OnlineConf differs from the config
+const DriftExitCode = client.ExitChanged
+
+/*
+go run ./cmd/yml2onlineconf drift -onlineConfURL https://onlineconf.local -importConfigFilepath ./importConfig.yml -headersFilepath ./headers.txt -mainNodeName importConfig
+*/
+func drift(args []string) {
+
+	flags := flag.NewFlagSet("drift", flag.ExitOnError)
+	clientFlags := clientflags.Register(flags)
+	var configFilepaths filepathsFlag
+	flags.Var(&configFilepaths, "importConfigFilepath", "import config filepath, repeat to merge overlay layers in order")
+	documents := flags.String("documents", string(parser.DocumentsMerge), "how documents of multi-document yaml are combined: merge - later documents override keys of earlier ones, separate - every document is imported to the node named by its index")
+	interpolateEnv := flags.Bool("interpolateEnv", false, "Replace ${VAR} and ${VAR:-default} in values with environment variables, $$ is the dollar sign, values tagged as !env are always replaced")
+	ignoredNodes := flags.String("ignoredNodes", "", "comma separated patterns of nodes which are not compared")
+	reportFilepath := flags.String("reportFilepath", "-", "file to write JSON report to, - for stdout")
+
+	flags.Parse(args)
+
+	if len(configFilepaths) == 0 {
+		log.Fatal(fmt.Errorf("import filepath config is empty"))
+	}
+	documentsMode, err := parser.ParseDocumentsMode(*documents)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ignored := []string{}
+	if *ignoredNodes != "" {
+		ignored = strings.Split(*ignoredNodes, ",")
+	}
+
+	redactor, err := clientFlags.NewRedactor()
+	if err != nil {
+		log.Fatal(err)
+	}
+	layers, err := parser.GetYMLLayers(configFilepaths, documentsMode, *interpolateEnv)
+	if err != nil {
+		log.Fatal(err)
+	}
+	redactor.AddKeys(layers.SecretKeys)
+
+	onlineConfClient, err := clientFlags.NewClient(redactor)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	src := parser.WalkByYMLNode(layers.Node, "", false)
+
+	report, err := onlineConfClient.GetDrift(context.Background(), *clientFlags.MainNodeName, src, ignored)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = saveReport(*reportFilepath, report.Redacted(redactor).Write)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if report.Drifted {
+		log.Printf("drift: %d missing, %d changed, %d extra\n",
+			report.Summary[string(client.DriftMissing)], report.Summary[string(client.DriftChanged)], report.Summary[string(client.DriftExtra)])
+		os.Exit(DriftExitCode)
+	}
+}
@@ -1,97 +1,423 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
-	"reflect"
-	"regexp"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"log"
 
+	"onlineconf-yaml/cmd/internal/clientflags"
+	"onlineconf-yaml/journal"
 	client "onlineconf-yaml/onlineconf"
+	"onlineconf-yaml/state"
 	"onlineconf-yaml/yml/parser"
 )
 
 /*
-go run cmd/yml2onlineconf/main.go -onlineConfURL https://onlineconf.local -importConfigFilepath ./importConfig.yml -headersFilepath ./headers.txt -mainNodeName importConfig -showParsedConfig -importParsedConfig
+go run ./cmd/yml2onlineconf -onlineConfURL https://onlineconf.local -importConfigFilepath ./importConfig.yml -headersFilepath ./headers.txt -mainNodeName importConfig -showParsedConfig -importParsedConfig
 */
 func main() {
 
-	onlineConfURL := flag.String("onlineConfURL", "https://onlineconf.local", "OnlineConf URL name")
-	configFilepath := flag.String("importConfigFilepath", "", "import config filepath")
-	headersFilepath := flag.String("headersFilepath", "", "file with raw browser headers")
-	mainNodeName := flag.String("mainNodeName", "", "OnlineConf main node name")
+	if len(os.Args) > 1 && os.Args[1] == "drift" {
+		drift(os.Args[2:])
+		return
+	}
+
+	clientFlags := clientflags.Register(flag.CommandLine)
+	var configFilepaths filepathsFlag
+	flag.Var(&configFilepaths, "importConfigFilepath", "import config filepath, repeat to merge overlay layers in order, e.g. base and production")
+	documents := flag.String("documents", string(parser.DocumentsMerge), "how documents of multi-document yaml are combined: merge - later documents override keys of earlier ones, separate - every document is imported to the node named by its index")
+	interpolateEnv := flag.Bool("interpolateEnv", false, "Replace ${VAR} and ${VAR:-default} in values with environment variables, $$ is the dollar sign, values tagged as !env are always replaced")
 	showParsedConfig := flag.Bool("showParsedConfig", false, "Show parsed config")
 	importParsedConfig := flag.Bool("importParsedConfig", false, "Import parsed config to OnlineConf")
 	updateIfExists := flag.Bool("updateIfExists", false, "Update node value if already exists")
 	deleteParsedConfig := flag.Bool("deleteParsedConfig", false, "Delete config in OnlineConf")
 	skipAlreadyExist := flag.Bool("skipAlreadyExist", false, "Skip already exist error")
 	skipCreateNode := flag.Bool("skipCreateNode", false, "Skip create node")
-	basicAuthKey := flag.String("basicAuthKey", "", "Basic autorization key (docker only)")
 	comment := flag.String("comment", "", "Comment message")
+	planParsedConfig := flag.Bool("planParsedConfig", false, "Show changes required to sync OnlineConf with parsed config")
+	applyParsedConfig := flag.Bool("applyParsedConfig", false, "Apply changes required to sync OnlineConf with parsed config")
+	planFilepath := flag.String("planFilepath", "", "file to save plan to, or to load plan from when applying without planning")
+	pruneParsedConfig := flag.Bool("pruneParsedConfig", false, "Delete nodes which are not present in parsed config")
+	protectedNodes := flag.String("protectedNodes", "", "comma separated patterns of nodes which are never deleted")
+	maxDeletions := flag.Int("maxDeletions", 10, "maximum number of nodes to delete, 0 - no limit")
+	concurrency := flag.Int("concurrency", 1, "number of parallel requests on import")
+	stateFilepath := flag.String("stateFilepath", "", "file with values applied by previous imports, used to detect nodes changed in OnlineConf")
+	conflictPolicy := flag.String("conflictPolicy", string(client.ConflictRefuse), "what to do with nodes changed in OnlineConf since the last import: refuse, overwrite, keep or prompt")
+	trustState := flag.Bool("trustState", false, "Skip requests for nodes whose value in the state file equals parsed config")
+	stateLockTimeout := flag.Duration("stateLockTimeout", time.Minute, "how long to wait for the state file locked by another process")
+	journalFilepath := flag.String("journalFilepath", "", "file to record completed operations to resume the failed import")
+	resume := flag.Bool("resume", false, "Skip operations recorded in the journal by the failed import")
+	reportFilepath := flag.String("reportFilepath", "", "file to write JSON report of node results to, - for stdout")
+	detailedExitCode := flag.Bool("detailedExitCode", false, "Exit with 0 if nothing is changed, 1 on failure, 2 if changes are applied")
+	atomic := flag.Bool("atomic", false, "Revert nodes changed by the import if any node fails")
+	pruneManagedOnly := flag.Bool("pruneManagedOnly", false, "Delete only nodes created by imports recorded in the state file")
 
 	flag.Parse()
 
-	if *configFilepath == "" {
+	pruneOptions := client.PruneOptions{
+		MaxDeletions: *maxDeletions,
+		ManagedOnly:  *pruneManagedOnly,
+	}
+	if *protectedNodes != "" {
+		pruneOptions.ProtectedPaths = strings.Split(*protectedNodes, ",")
+	}
+
+	applySavedPlan := *applyParsedConfig && !*planParsedConfig && *planFilepath != ""
+	if *resume && *journalFilepath == "" {
+		log.Fatal(fmt.Errorf("journal filepath is required to resume"))
+	}
+	if *atomic && *resume {
+		log.Fatal(fmt.Errorf("atomic import reverts all changes on failure, there is nothing to resume"))
+	}
+	if len(configFilepaths) == 0 && !applySavedPlan {
 		log.Fatal(fmt.Errorf("import filepath config is empty"))
 	}
+	documentsMode, err := parser.ParseDocumentsMode(*documents)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	*onlineConfURL = regexp.MustCompile(`/+$`).ReplaceAllString(*onlineConfURL, "")
-	client, err := client.NewOnlineConfClient(
-		fmt.Sprintf("%s/%s/%s", *onlineConfURL, client.URLPrefix, *mainNodeName),
-		*headersFilepath,
-		*basicAuthKey,
-	)
+	redactor, err := clientFlags.NewRedactor()
 	if err != nil {
 		log.Fatal(err)
 	}
+	var layers *parser.Layers
+	if len(configFilepaths) > 0 {
+		layers, err = parser.GetYMLLayers(configFilepaths, documentsMode, *interpolateEnv)
+		if err != nil {
+			log.Fatal(err)
+		}
+		redactor.AddKeys(layers.SecretKeys)
+	}
 
-	data, err := parser.GetYMLConfig(*configFilepath)
+	onlineConfClient, err := clientFlags.NewClient(redactor)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	obj := reflect.ValueOf(&data)
-	src := parser.WalkByYML(obj, "", false)
+	policy, err := client.ParseConflictPolicy(*conflictPolicy)
+	if err != nil {
+		log.Fatal(err)
+	}
+	importOptions := client.ImportOptions{
+		UpdateIfExists:   *updateIfExists,
+		SkipAlreadyExist: *skipAlreadyExist,
+		SkipCreateNode:   *skipCreateNode,
+		Comment:          *comment,
+		Concurrency:      *concurrency,
+		ConflictPolicy:   policy,
+		Prompt:           promptConflict(redactor),
+		TrustLastApplied: *trustState,
+	}
+	if *atomic {
+		importOptions.Changes = &client.Changes{}
+	}
+	if *reportFilepath != "" || *detailedExitCode {
+		importOptions.Report = client.NewReport(*clientFlags.MainNodeName)
+	}
+	if *reportFilepath == "-" {
+		stdout = os.Stderr
+	}
+	// report is written on exit
+	writeReport := func(err error) {
+		if importOptions.Report == nil {
+			return
+		}
+		importOptions.Report.Finish(err)
+		if *reportFilepath == "" {
+			return
+		}
+		if err := saveReport(*reportFilepath, importOptions.Report.Write); err != nil {
+			log.Printf("ERROR: can't write report: %+v\n", err)
+		}
+	}
 
-	if *showParsedConfig {
-		for k, v := range src {
-			fmt.Printf("%-50s (%-30s) : %v\n", k, v.Type, v.Value)
+	// state is saved and unlocked on exit
+	finish := func() {}
+	if *stateFilepath != "" {
+		lock, err := state.AcquireLock(*stateFilepath, *stateLockTimeout)
+		if err != nil {
+			log.Fatal(err)
+		}
+		importOptions.LastApplied, err = state.Load(*stateFilepath, *clientFlags.MainNodeName)
+		if err != nil {
+			lock.Release()
+			log.Fatal(err)
+		}
+		// the second interrupt finishes concurrently with the import
+		var once sync.Once
+		finish = func() {
+			once.Do(func() {
+				if err := importOptions.LastApplied.Save(*stateFilepath); err != nil {
+					log.Printf("ERROR: can't save state: %+v\n", err)
+				}
+				if err := lock.Release(); err != nil {
+					log.Printf("ERROR: can't unlock state: %+v\n", err)
+				}
+			})
 		}
 	}
 
-	nodeKeys := parser.GetParentNodeKeys(src)
+	// journal is kept to resume the failed import and removed after success
+	var importJournal *journal.Journal
+	fatal := func(err error) {
+		if importOptions.Changes != nil && importOptions.Changes.Len() > 0 {
+			log.Printf("ERROR: %+v, rolling back %d nodes\n", err, importOptions.Changes.Len())
+			rollbackErr := onlineConfClient.Rollback(context.Background(), importOptions.Changes, importOptions)
+			if rollbackErr != nil {
+				log.Printf("ERROR: %+v\n", rollbackErr)
+			} else if importJournal != nil {
+				importJournal.Remove()
+				importJournal = nil
+			}
+		}
+		if importJournal != nil {
+			importJournal.Close()
+			log.Printf("completed operations are saved to %s, run with -resume to continue\n", *journalFilepath)
+		}
+		finish()
+		writeReport(err)
+		log.Fatal(err)
+	}
+	success := func() {
+		if importJournal != nil {
+			if err := importJournal.Remove(); err != nil {
+				log.Printf("ERROR: can't remove journal: %+v\n", err)
+			}
+		}
+		finish()
+		writeReport(nil)
+		if *detailedExitCode {
+			os.Exit(importOptions.Report.ExitCode())
+		}
+	}
+	openJournal := func(data []byte) {
+		if *journalFilepath == "" {
+			return
+		}
+		importJournal, err = journal.Open(*journalFilepath, *clientFlags.MainNodeName, journal.Fingerprint(data), *resume)
+		if err != nil {
+			fatal(err)
+		}
+		importOptions.Journal = importJournal
+		log.Printf("journal %s, completed operations: %d\n", *journalFilepath, importJournal.Len())
+	}
 
-	if *importParsedConfig {
-		if !*skipCreateNode {
-			log.Printf("client.CreateEmptyNode..")
+	// the first interrupt stops the import after in-flight requests, the second one exits immediately
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		log.Printf("interrupted, finishing in-flight requests, interrupt again to exit immediately\n")
+		close(stop)
+		<-signals
+		// journal records are synced on write, state is saved and unlocked for the next run
+		finish()
+		if *journalFilepath != "" {
+			log.Printf("completed operations are saved to %s, run with -resume to continue\n", *journalFilepath)
+		}
+		os.Exit(130)
+	}()
+	importOptions.Stop = stop
 
-			for _, key := range nodeKeys {
-				err := client.CreateEmptyNode(key, *skipAlreadyExist, *comment)
-				if err != nil {
-					log.Fatal(err)
-				}
+	ctx := context.Background()
+
+	if applySavedPlan {
+		plan, err := client.LoadPlan(*planFilepath)
+		if err != nil {
+			fatal(err)
+		}
+		if plan.Root != *clientFlags.MainNodeName {
+			fatal(fmt.Errorf("plan is made for the node '%s', not '%s'", plan.Root, *clientFlags.MainNodeName))
+		}
+		planData, err := json.Marshal(plan)
+		if err != nil {
+			fatal(err)
+		}
+		openJournal(planData)
+
+		err = preparePlan(plan, pruneOptions, importOptions)
+		if err != nil {
+			fatal(err)
+		}
+		err = applyPlan(ctx, onlineConfClient, plan, pruneOptions, importOptions, redactor)
+		if err != nil {
+			fatal(err)
+		}
+		success()
+		return
+	}
+
+	src := parser.WalkByYMLNode(layers.Node, "", false)
+
+	if *showParsedConfig {
+		for k, v := range src {
+			if len(configFilepaths) > 1 {
+				fmt.Fprintf(stdout, "%-50s (%-30s) : %v (from %s)\n", k, v.Type, redactor.Value(k, v.Value), layers.Origin(k))
+				continue
 			}
+			fmt.Fprintf(stdout, "%-50s (%-30s) : %v\n", k, v.Type, redactor.Value(k, v.Value))
 		}
+	}
 
-		for _, v := range src {
+	srcData, err := json.Marshal(src)
+	if err != nil {
+		fatal(err)
+	}
+	openJournal(srcData)
+
+	if *planParsedConfig || *applyParsedConfig {
+		plan, err := onlineConfClient.GetPlan(ctx, *clientFlags.MainNodeName, src)
+		if err != nil {
+			fatal(err)
+		}
+		err = preparePlan(plan, pruneOptions, importOptions)
+		if err != nil {
+			fatal(err)
+		}
 
-			err = client.CreateNode(v, *updateIfExists, *skipAlreadyExist, *comment)
+		if *planFilepath != "" && *planParsedConfig {
+			err = plan.Save(*planFilepath)
 			if err != nil {
-				log.Fatal(err)
+				fatal(err)
 			}
 		}
+
+		if *applyParsedConfig {
+			err = applyPlan(ctx, onlineConfClient, plan, pruneOptions, importOptions, redactor)
+		} else {
+			plan.Redacted(redactor).Print(stdout, false)
+		}
+		if err != nil {
+			fatal(err)
+		}
+	}
+
+	if *importParsedConfig {
+		stats, err := onlineConfClient.Import(ctx, src, importOptions)
+		log.Printf("created: %d, updated: %d, unchanged: %d, kept: %d, skipped: %d\n",
+			stats[client.NodeCreated], stats[client.NodeUpdated], stats[client.NodeUnchanged], stats[client.NodeKept], stats[client.NodeSkipped])
+		if err != nil {
+			fatal(err)
+		}
+	}
+
+	if *pruneParsedConfig {
+		plan, err := onlineConfClient.PruneNodes(ctx, *clientFlags.MainNodeName, src, pruneOptions, importOptions)
+		if plan != nil {
+			plan.Redacted(redactor).Print(stdout, false)
+		}
+		if err != nil {
+			fatal(err)
+		}
 	}
 
 	if *deleteParsedConfig {
-		nodeKeys = parser.GetNodeKeysForDelete(src)
+		nodeKeys := parser.GetNodeKeysForDelete(src)
 		log.Printf("delete =============> %+v\n", nodeKeys)
 
-		for _, key := range nodeKeys {
-			err := client.DeleteNode(key, *comment)
-			if err != nil {
-				log.Fatal(err)
-			}
+		err = onlineConfClient.DeleteNodes(ctx, nodeKeys, importOptions)
+		if err != nil {
+			fatal(err)
 		}
 	}
+
+	success()
+}
+
+// preparePlan protect nodes from deletion and mark nodes changed in OnlineConf since the last import
+func preparePlan(plan *client.Plan, pruneOptions client.PruneOptions, importOptions client.ImportOptions) error {
+	err := plan.Protect(pruneOptions.ProtectedPaths)
+	if err != nil {
+		return err
+	}
+	if importOptions.LastApplied != nil {
+		if pruneOptions.ManagedOnly {
+			plan.Scope(importOptions.LastApplied)
+		}
+		plan.MarkDrifted(importOptions.LastApplied)
+	}
+	return nil
+}
+
+// applyPlan print and apply the prepared plan
+func applyPlan(ctx context.Context, onlineConfClient *client.OnlineConfClient, plan *client.Plan, pruneOptions client.PruneOptions, importOptions client.ImportOptions, redactor *client.Redactor) error {
+	plan.Redacted(redactor).Print(stdout, false)
+
+	err := plan.CheckDeletions(pruneOptions.MaxDeletions)
+	if err != nil {
+		return err
+	}
+	return onlineConfClient.ApplyPlan(ctx, plan, importOptions)
+}
+
+var (
+	promptMu sync.Mutex
+	stdin    = bufio.NewReader(os.Stdin)
+	// stdout is replaced by stderr when the report is written to stdout
+	stdout io.Writer = os.Stdout
+)
+
+// promptConflict ask whether to overwrite the node changed in OnlineConf, secret values are masked
+func promptConflict(redactor *client.Redactor) func(conflict client.Conflict) (bool, error) {
+	return func(conflict client.Conflict) (bool, error) {
+		promptMu.Lock()
+		defer promptMu.Unlock()
+
+		fmt.Fprintf(stdout, "%s was changed in OnlineConf since the last import\n", conflict.Key)
+		fmt.Fprintf(stdout, "  OnlineConf (%s) : %v\n", conflict.LiveType, redactor.Value(conflict.Key, conflict.LiveValue))
+		if conflict.NewType == "" {
+			fmt.Fprintf(stdout, "  config     : deleted\n")
+		} else {
+			fmt.Fprintf(stdout, "  config     (%s) : %v\n", conflict.NewType, redactor.Value(conflict.Key, conflict.NewValue))
+		}
+		fmt.Fprint(stdout, "Overwrite? [y/N] ")
+
+		answer, err := stdin.ReadString('\n')
+		if err != nil {
+			return false, err
+		}
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		return answer == "y" || answer == "yes", nil
+	}
+}
+
+// saveReport write report to the file, - for stdout
+func saveReport(filepath string, write func(w io.Writer) error) error {
+	if filepath == "-" {
+		return write(os.Stdout)
+	}
+	file, err := os.Create(filepath)
+	if err != nil {
+		return err
+	}
+	err = write(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// filepathsFlag repeated filepath flag
+type filepathsFlag []string
+
+func (filepaths *filepathsFlag) String() string {
+	return strings.Join(*filepaths, ",")
+}
+
+func (filepaths *filepathsFlag) Set(value string) error {
+	*filepaths = append(*filepaths, value)
+	return nil
 }
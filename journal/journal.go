@@ -0,0 +1,169 @@
+// Package journal records completed operations of the import to resume it after a failure
+package journal
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Entry completed operation
+type Entry struct {
+	Operation string    `json:"operation"`
+	Result    string    `json:"result"`
+	Version   int       `json:"version,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+type header struct {
+	Root        string `json:"root"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// Journal append-only file of completed operations, safe for concurrent use
+type Journal struct {
+	mu       sync.Mutex
+	filepath string
+	file     *os.File
+	done     map[string]Entry
+}
+
+// Fingerprint fingerprint of the imported data, resume is allowed only for the same data
+func Fingerprint(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Open start new journal, or continue the existing one if resume is set
+func Open(filepath string, root string, fingerprint string, resume bool) (*Journal, error) {
+	journal := &Journal{
+		filepath: filepath,
+		done:     map[string]Entry{},
+	}
+
+	if resume {
+		size, err := journal.load(root, fingerprint)
+		if err != nil {
+			return nil, err
+		}
+		// drop the line cut by the crash to append after the last complete one
+		err = os.Truncate(filepath, size)
+		if err != nil {
+			return nil, err
+		}
+		file, err := os.OpenFile(filepath, os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		journal.file = file
+		return journal, nil
+	}
+
+	file, err := os.OpenFile(filepath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+	journal.file = file
+	err = journal.write(header{Root: root, Fingerprint: fingerprint})
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return journal, nil
+}
+
+func (journal *Journal) load(root string, fingerprint string) (int64, error) {
+	file, err := os.Open(journal.filepath)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, fmt.Errorf("nothing to resume, journal '%s' does not exist", journal.filepath)
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	if !scanner.Scan() {
+		return 0, fmt.Errorf("journal '%s' is empty", journal.filepath)
+	}
+	var h header
+	err = json.Unmarshal(scanner.Bytes(), &h)
+	if err != nil {
+		return 0, fmt.Errorf("can't parse journal '%s' header... %s", journal.filepath, err.Error())
+	}
+	if h.Root != root || h.Fingerprint != fingerprint {
+		return 0, fmt.Errorf("journal '%s' is made for another node or config", journal.filepath)
+	}
+	size := int64(len(scanner.Bytes()) + 1)
+
+	for scanner.Scan() {
+		var entry Entry
+		// the last line can be cut by the crash
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			break
+		}
+		journal.done[entry.Operation] = entry
+		size += int64(len(scanner.Bytes()) + 1)
+	}
+	return size, scanner.Err()
+}
+
+// Done completed operation, if any
+func (journal *Journal) Done(operation string) (Entry, bool) {
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+	entry, ok := journal.done[operation]
+	return entry, ok
+}
+
+// Len number of completed operations
+func (journal *Journal) Len() int {
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+	return len(journal.done)
+}
+
+// Record write completed operation to the disk
+func (journal *Journal) Record(operation string, result string, version int) error {
+	entry := Entry{
+		Operation: operation,
+		Result:    result,
+		Version:   version,
+		Time:      time.Now().UTC().Truncate(time.Second),
+	}
+
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+	journal.done[operation] = entry
+	return journal.write(entry)
+}
+
+func (journal *Journal) write(data interface{}) error {
+	line, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = journal.file.Write(append(line, '\n'))
+	if err != nil {
+		return err
+	}
+	return journal.file.Sync()
+}
+
+// Close close the journal, keep it to resume
+func (journal *Journal) Close() error {
+	return journal.file.Close()
+}
+
+// Remove close and delete the journal of the completed import
+func (journal *Journal) Remove() error {
+	journal.file.Close()
+	return os.Remove(journal.filepath)
+}
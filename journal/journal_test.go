@@ -0,0 +1,56 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournal(t *testing.T) {
+
+	journalFilepath := filepath.Join(t.TempDir(), "import.journal")
+	fingerprint := Fingerprint([]byte("config"))
+
+	_, err := Open(journalFilepath, "importConfig", fingerprint, true)
+	assert.Error(t, err)
+
+	journal, err := Open(journalFilepath, "importConfig", fingerprint, false)
+	require.NoError(t, err)
+	require.NoError(t, journal.Record("parent fee", "created", 1))
+	require.NoError(t, journal.Record("node fee/KEY1", "updated", 3))
+	require.NoError(t, journal.Close())
+
+	// crash in the middle of the line
+	file, err := os.OpenFile(journalFilepath, os.O_WRONLY|os.O_APPEND, 0644)
+	require.NoError(t, err)
+	file.WriteString(`{"operation":"node fee/KE`)
+	file.Close()
+
+	_, err = Open(journalFilepath, "importConfig", Fingerprint([]byte("changed")), true)
+	assert.Error(t, err)
+	_, err = Open(journalFilepath, "anotherConfig", fingerprint, true)
+	assert.Error(t, err)
+
+	journal, err = Open(journalFilepath, "importConfig", fingerprint, true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, journal.Len())
+	entry, ok := journal.Done("node fee/KEY1")
+	require.True(t, ok)
+	assert.Equal(t, "updated", entry.Result)
+	assert.Equal(t, 3, entry.Version)
+	_, ok = journal.Done("node fee/KEY2")
+	assert.False(t, ok)
+
+	require.NoError(t, journal.Record("node fee/KEY2", "created", 1))
+	require.NoError(t, journal.Close())
+
+	journal, err = Open(journalFilepath, "importConfig", fingerprint, true)
+	require.NoError(t, err)
+	assert.Equal(t, 3, journal.Len())
+	require.NoError(t, journal.Remove())
+	_, err = os.Stat(journalFilepath)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -37,6 +37,7 @@ export GOPATH=$(pwd)/go
 [ "$RPM_BUILD_ROOT" != "/" ] && rm -rf $RPM_BUILD_ROOT
 install -D $GOPATH/bin/yml2cdb $RPM_BUILD_ROOT%{_bindir}/yml2cdb
 install -D $GOPATH/bin/yml2onlineconf $RPM_BUILD_ROOT%{_bindir}/yml2onlineconf
+install -D $GOPATH/bin/onlineconf2yml $RPM_BUILD_ROOT%{_bindir}/onlineconf2yml
 mkdir -m 740 -p $RPM_BUILD_ROOT%{_sysconfdir}/%{name}
 
 %pre
@@ -50,3 +51,4 @@ mkdir -m 740 -p $RPM_BUILD_ROOT%{_sysconfdir}/%{name}
 %defattr(-,root,root)
 %{_bindir}/yml2cdb
 %{_bindir}/yml2onlineconf
+%{_bindir}/onlineconf2yml
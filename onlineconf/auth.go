@@ -0,0 +1,200 @@
+package client
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// environment variables with credentials
+const (
+	EnvToken        = "ONLINECONF_TOKEN"
+	EnvUsername     = "ONLINECONF_USERNAME"
+	EnvPassword     = "ONLINECONF_PASSWORD"
+	EnvBasicAuthKey = "ONLINECONF_BASIC_AUTH_KEY"
+)
+
+// Authenticator adds credentials to every request
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// BasicAuth username and password
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+// Authenticate set basic authorization header
+func (auth BasicAuth) Authenticate(req *http.Request) error {
+	req.SetBasicAuth(auth.Username, auth.Password)
+	return nil
+}
+
+// BasicAuthKey pre-encoded basic authorization key
+type BasicAuthKey string
+
+// Authenticate set basic authorization header
+func (auth BasicAuthKey) Authenticate(req *http.Request) error {
+	req.Header.Set("Authorization", "Basic "+string(auth))
+	return nil
+}
+
+// BearerAuth bearer token
+type BearerAuth string
+
+// Authenticate set bearer authorization header
+func (auth BearerAuth) Authenticate(req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+string(auth))
+	return nil
+}
+
+// AuthOptions credentials, the first configured method is used: token, username and password,
+// basic auth key, environment variables, netrc
+type AuthOptions struct {
+	Token    string
+	Username string
+	// Password is taken from ONLINECONF_PASSWORD if empty
+	Password     string
+	BasicAuthKey string
+	// NetrcFilepath netrc is looked up for the OnlineConf host if set
+	NetrcFilepath string
+}
+
+// NewAuth authenticator of the options, nil if no credentials are configured
+func NewAuth(options AuthOptions, onlineConfURL string) (Authenticator, error) {
+	switch {
+	case options.Token != "":
+		return BearerAuth(options.Token), nil
+	case options.Username != "":
+		password := options.Password
+		if password == "" {
+			password = os.Getenv(EnvPassword)
+		}
+		return BasicAuth{Username: options.Username, Password: password}, nil
+	case options.BasicAuthKey != "":
+		return BasicAuthKey(options.BasicAuthKey), nil
+	case os.Getenv(EnvToken) != "":
+		return BearerAuth(os.Getenv(EnvToken)), nil
+	case os.Getenv(EnvUsername) != "":
+		return BasicAuth{Username: os.Getenv(EnvUsername), Password: os.Getenv(EnvPassword)}, nil
+	case os.Getenv(EnvBasicAuthKey) != "":
+		return BasicAuthKey(os.Getenv(EnvBasicAuthKey)), nil
+	case options.NetrcFilepath != "":
+		u, err := url.Parse(onlineConfURL)
+		if err != nil {
+			return nil, err
+		}
+		auth, err := LookupNetrc(options.NetrcFilepath, u.Hostname())
+		if err != nil || auth == nil {
+			return nil, err
+		}
+		return *auth, nil
+	}
+	return nil, nil
+}
+
+// DefaultNetrcFilepath $NETRC or ~/.netrc
+func DefaultNetrcFilepath() string {
+	if netrc := os.Getenv("NETRC"); netrc != "" {
+		return netrc
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".netrc")
+}
+
+// LookupNetrc credentials of the host from the netrc file, default entry if the host is not found,
+// nil if there are no credentials
+func LookupNetrc(filepath string, host string) (*BasicAuth, error) {
+	file, err := os.Open(filepath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	tokens := []string{}
+	scanner := bufio.NewScanner(file)
+	inMacro := false
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		// macro definition ends with an empty line
+		if inMacro {
+			inMacro = line != ""
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		for i, field := range fields {
+			if field == "macdef" {
+				inMacro = true
+				fields = fields[:i]
+				break
+			}
+		}
+		tokens = append(tokens, fields...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var (
+		found    *BasicAuth
+		fallback *BasicAuth
+		current  *BasicAuth
+	)
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "machine":
+			if i+1 >= len(tokens) {
+				return nil, fmt.Errorf("bad netrc file '%s'... machine name is missing", filepath)
+			}
+			i++
+			current = &BasicAuth{}
+			if tokens[i] == host && found == nil {
+				found = current
+			}
+		case "default":
+			current = &BasicAuth{}
+			if fallback == nil {
+				fallback = current
+			}
+		case "login", "password", "account":
+			if i+1 >= len(tokens) {
+				return nil, fmt.Errorf("bad netrc file '%s'... %s value is missing", filepath, tokens[i])
+			}
+			i++
+			if current == nil {
+				continue
+			}
+			if tokens[i-1] == "login" {
+				current.Username = tokens[i]
+			} else if tokens[i-1] == "password" {
+				current.Password = tokens[i]
+			}
+		}
+	}
+	if found != nil {
+		return found, nil
+	}
+	return fallback, nil
+}
+
+// WithAuth add credentials of the authenticator to every request
+func WithAuth(auth Authenticator) Option {
+	return func(client *OnlineConfClient) {
+		client.auth = auth
+	}
+}
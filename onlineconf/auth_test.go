@@ -0,0 +1,108 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAuth(t *testing.T) {
+
+	netrcFilepath := filepath.Join(t.TempDir(), ".netrc")
+	require.NoError(t, os.WriteFile(netrcFilepath, []byte(`
+# comment
+machine other.local login other password other
+machine onlineconf.local
+  login netrc-user
+  password netrc-password
+macdef init
+  machine onlineconf.local login macro password macro
+
+default login default-user password default-password
+`), 0600))
+
+	for _, env := range []string{EnvToken, EnvUsername, EnvPassword, EnvBasicAuthKey} {
+		t.Setenv(env, "")
+	}
+
+	cases := []struct {
+		options  AuthOptions
+		env      map[string]string
+		expected Authenticator
+	}{
+		{AuthOptions{}, nil, nil},
+		{AuthOptions{Token: "token", Username: "user"}, nil, BearerAuth("token")},
+		{AuthOptions{Username: "user", Password: "pass", BasicAuthKey: "key"}, nil, BasicAuth{Username: "user", Password: "pass"}},
+		{AuthOptions{Username: "user"}, map[string]string{EnvPassword: "env-pass"}, BasicAuth{Username: "user", Password: "env-pass"}},
+		{AuthOptions{BasicAuthKey: "key"}, map[string]string{EnvToken: "env-token"}, BasicAuthKey("key")},
+		{AuthOptions{}, map[string]string{EnvToken: "env-token"}, BearerAuth("env-token")},
+		{AuthOptions{}, map[string]string{EnvUsername: "env-user", EnvPassword: "env-pass"}, BasicAuth{Username: "env-user", Password: "env-pass"}},
+		{AuthOptions{}, map[string]string{EnvBasicAuthKey: "env-key"}, BasicAuthKey("env-key")},
+		{AuthOptions{NetrcFilepath: netrcFilepath}, nil, BasicAuth{Username: "netrc-user", Password: "netrc-password"}},
+	}
+	for i, c := range cases {
+		for name, value := range c.env {
+			t.Setenv(name, value)
+		}
+		auth, err := NewAuth(c.options, "https://onlineconf.local:8443/")
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, auth, i)
+		for name := range c.env {
+			t.Setenv(name, "")
+		}
+	}
+
+	auth, err := LookupNetrc(netrcFilepath, "unknown.local")
+	require.NoError(t, err)
+	assert.Equal(t, &BasicAuth{Username: "default-user", Password: "default-password"}, auth)
+
+	auth, err = LookupNetrc(filepath.Join(t.TempDir(), "missing"), "onlineconf.local")
+	require.NoError(t, err)
+	assert.Nil(t, auth)
+}
+
+func TestClientAuth(t *testing.T) {
+
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.Write([]byte(`{"name":"KEY1","path":"/KEY1","data":"4","mime":"text/plain","version":1}`))
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	client, err := NewOnlineConfClient(server.URL, "", "", WithAuth(BearerAuth("token")))
+	require.NoError(t, err)
+	_, err = client.GetNode(ctx, "KEY1")
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer token", authorization)
+
+	client, err = NewOnlineConfClient(server.URL, "", "a2V5")
+	require.NoError(t, err)
+	_, err = client.GetNode(ctx, "KEY1")
+	require.NoError(t, err)
+	assert.Equal(t, "Basic a2V5", authorization)
+
+	// credentials in the url
+	userURL := strings.Replace(server.URL, "http://", "http://user:secret-password@", 1)
+	client, err = NewOnlineConfClient(userURL, "", "", WithLogLevel(LogDebug))
+	require.NoError(t, err)
+	_, err = client.GetNode(ctx, "KEY1")
+	require.NoError(t, err)
+	assert.Equal(t, "Basic dXNlcjpzZWNyZXQtcGFzc3dvcmQ=", authorization)
+	assert.Contains(t, buf.String(), server.URL+"/KEY1")
+	assert.NotContains(t, buf.String(), "secret-password")
+}
@@ -1,18 +1,16 @@
 package client
 
 import (
-	"bufio"
-	"crypto/tls"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"net/url"
+	"onlineconf-yaml/state"
 	"onlineconf-yaml/yml/parser"
-	"os"
-	"strconv"
 	"strings"
+	"time"
 )
 
 // URLPrefix prefix url constant
@@ -20,8 +18,14 @@ const URLPrefix = "config"
 
 // OnlineConfClient onlineconf client
 type OnlineConfClient struct {
-	host    string
-	headers map[string]string
+	host         string
+	headers      map[string]string
+	httpClient   *http.Client
+	retries      int
+	retryBackoff time.Duration
+	logLevel     LogLevel
+	redactor     *Redactor
+	auth         Authenticator
 }
 
 // OnlineConfResponse onlineconf response
@@ -36,11 +40,19 @@ func NewOnlineConfClient(
 	host string,
 	filepathHeader string,
 	basicAuthKey string,
+	options ...Option,
 ) (*OnlineConfClient, error) {
 
 	client := &OnlineConfClient{
-		headers: map[string]string{},
-		host:    host,
+		headers:      map[string]string{},
+		host:         host,
+		httpClient:   newHTTPClient(),
+		retries:      DefaultRetries,
+		retryBackoff: DefaultRetryBackoff,
+		logLevel:     DefaultLogLevel,
+	}
+	for _, option := range options {
+		option(client)
 	}
 
 	if filepathHeader != "" {
@@ -52,172 +64,290 @@ func NewOnlineConfClient(
 	} else {
 		client.headers = map[string]string{
 			"X-Requested-With": "XMLHttpRequest",
-			"Authorization":    fmt.Sprintf("Basic %s", basicAuthKey),
 		}
 	}
-
-	return client, nil
-}
-
-// GetHeaders return headers
-func (client *OnlineConfClient) GetHeaders(filepath string) (map[string]string, error) {
-
-	headers := map[string]string{}
-	if filepath == "" {
-		return headers, nil
+	if client.auth == nil && basicAuthKey != "" {
+		client.auth = BasicAuthKey(basicAuthKey)
 	}
 
-	file, err := os.Open(filepath)
+	// credentials in the url are moved to the authorization header to keep them out of the log
+	hostURL, err := url.Parse(host)
 	if err != nil {
-		return headers, err
+		return nil, fmt.Errorf("bad OnlineConf url...%s", err.Error())
 	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		list := strings.Split(line, ":")
-		if len(list) != 2 {
-			continue
+	if hostURL.User != nil {
+		if client.auth == nil {
+			password, _ := hostURL.User.Password()
+			client.auth = BasicAuth{Username: hostURL.User.Username(), Password: password}
 		}
-		headers[strings.TrimSpace(list[0])] = strings.TrimSpace(list[1])
-	}
-	if err := scanner.Err(); err != nil {
-		return headers, err
+		hostURL.User = nil
+		client.host = hostURL.String()
 	}
-	return headers, nil
+
+	return client, nil
 }
 
 // CreateEmptyNode creating empty node
-func (client *OnlineConfClient) CreateEmptyNode(key string, skipAlreadyExist bool, comment string) error {
+func (client *OnlineConfClient) CreateEmptyNode(ctx context.Context, key string, skipAlreadyExist bool, comment string) error {
+	_, err := client.createEmptyNode(ctx, key, skipAlreadyExist, comment)
+	return err
+}
+
+// createEmptyNode returns version of the created node, 0 if the node already exists
+func (client *OnlineConfClient) createEmptyNode(ctx context.Context, key string, skipAlreadyExist bool, comment string) (int, error) {
 	params := map[string]string{
 		"summary":      "",
 		"description":  "",
 		"notification": "",
-		"mime":         "application/x-null",
+		"mime":         NullMime,
 		"data":         "",
 		"comment":      comment,
 	}
 
-	statusCode, result, err := client.request(key, http.MethodPost, params)
-	log.Printf("init key %s, status: %+v, result: %+v, err: %+v\n", key, statusCode, result, err)
-	if err != nil {
-		return err
-	}
-
-	var response OnlineConfResponse
-	err = json.Unmarshal([]byte(result), &response)
+	statusCode, result, err := client.request(ctx, key, http.MethodPost, params)
+	client.logf(LogInfo, "init key %s, status: %+v, err: %+v\n", key, statusCode, err)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	if statusCode != http.StatusOK {
-		if response.Error == "AlreadyExists" && skipAlreadyExist {
-			return nil
+		err := newResponseError(statusCode, result)
+		if errors.Is(err, ErrAlreadyExists) && skipAlreadyExist {
+			return 0, nil
 		}
-		return fmt.Errorf("create empty node failure...status: %v, result: %v", statusCode, result)
+		return 0, fmt.Errorf("create empty node failure...%w", err)
 	}
-	return err
+	return responseVersion(result), nil
 }
 
-// CreateNode create node
-func (client *OnlineConfClient) CreateNode(item parser.OnlineConfItem, updateIfExists bool, skipAlreadyExist bool, comment string) error {
+// NodeAction result of the node import
+type NodeAction string
+
+// node import results
+const (
+	NodeCreated   NodeAction = "created"
+	NodeUpdated   NodeAction = "updated"
+	NodeUnchanged NodeAction = "unchanged"
+	NodeSkipped   NodeAction = "skipped"
+	NodeKept      NodeAction = "kept"
+	NodeDeleted   NodeAction = "deleted"
+)
 
-	params := map[string]string{
-		"summary":      "",
-		"description":  "",
-		"notification": "",
-		"mime":         item.Type,
-		"data":         item.Value,
-		"comment":      comment,
+// CreateNode create node, existing node is updated only if its value, mime or metadata differ
+func (client *OnlineConfClient) CreateNode(ctx context.Context, item parser.OnlineConfItem, updateIfExists bool, skipAlreadyExist bool, comment string) (NodeAction, error) {
+	return client.createNode(ctx, item, ImportOptions{
+		UpdateIfExists:   updateIfExists,
+		SkipAlreadyExist: skipAlreadyExist,
+		Comment:          comment,
+	})
+}
+
+func (client *OnlineConfClient) createNode(ctx context.Context, item parser.OnlineConfItem, options ImportOptions) (NodeAction, error) {
+	start := time.Now()
+	action, oldVersion, version, err := client.createOrUpdateNode(ctx, item, options)
+	if err == nil && options.LastApplied != nil {
+		switch action {
+		case NodeCreated, NodeUpdated, NodeUnchanged:
+			options.LastApplied.Set(item.Key, item.Type, item.Value, version)
+		}
 	}
+	options.report(item.Key, action, oldVersion, version, start, err)
+	return action, err
+}
 
-	log.Printf("creation key: %+v\n", item.Key)
+// createOrUpdateNode returns the action, old and new versions
+func (client *OnlineConfClient) createOrUpdateNode(ctx context.Context, item parser.OnlineConfItem, options ImportOptions) (NodeAction, int, int, error) {
+	var (
+		entry state.Entry
+		known bool
+	)
+	if options.LastApplied != nil {
+		entry, known = options.LastApplied.Get(item.Key)
+	}
+	// metadata is not kept in the state, nodes with metadata are always checked
+	if known && options.TrustLastApplied && item.Meta.IsEmpty() && entry.Matches(item.Type, item.Value) {
+		return NodeUnchanged, entry.Version, entry.Version, nil
+	}
 
-	statusCode, result, err := client.request(item.Key, http.MethodPost, params)
-	log.Printf("POST status: %+v, result: %+v, err: %+v\n", statusCode, result, err)
-	if err != nil {
+	// node created by previous imports is updated without create attempt
+	if known && options.UpdateIfExists {
+		node, err := client.GetNode(ctx, item.Key)
+		if err != nil {
+			return "", 0, 0, err
+		}
+		if node != nil {
+			return client.updateNode(ctx, item, node, options)
+		}
+	}
 
-		return err
+	client.logf(LogInfo, "creation key: %+v\n", item.Key)
+
+	version, err := client.setNode(ctx, item.Key, item.Type, item.Value, item.Meta, 0, options.Comment)
+	if err == nil {
+		options.changed(Change{Key: item.Key, Action: PlanCreate, Version: version})
+		return NodeCreated, 0, version, nil
 	}
+	client.logf(LogError, "ERROR: err: %+v\n", err)
 
-	if statusCode != http.StatusOK {
-		err := fmt.Errorf("create node failure...status: %v, result: %v", statusCode, result)
-		log.Printf("ERROR: err: %+v\n", err)
+	if !errors.Is(err, ErrAlreadyExists) {
+		return "", 0, 0, err
+	}
 
-		if statusCode != http.StatusBadRequest {
-			return err
+	if options.UpdateIfExists {
+		node, err := client.GetNode(ctx, item.Key)
+		if err != nil {
+			return "", 0, 0, err
+		}
+		if node == nil {
+			client.logf(LogInfo, "GET key %s, exists: false\n", item.Key)
+			return NodeSkipped, 0, 0, nil
 		}
+		return client.updateNode(ctx, item, node, options)
+	}
 
-		if updateIfExists {
+	if options.SkipAlreadyExist {
+		return NodeSkipped, 0, 0, nil
+	}
 
-			statusCode, result, err = client.request(item.Key, http.MethodGet, nil)
-			if statusCode != http.StatusOK {
-				log.Printf("GET status: %+v, result: %+v, err: %+v\n", statusCode, result, err)
-				return nil
-			}
+	return "", 0, 0, err
+}
 
-			var response OnlineConfResponse
-			err = json.Unmarshal([]byte(result), &response)
+// updateNode update existing node if its value, mime or metadata differ, returns the action, old and new versions,
+// parent node of the config keeps its data, only its metadata is updated
+func (client *OnlineConfClient) updateNode(ctx context.Context, item parser.OnlineConfItem, node *Node, options ImportOptions) (NodeAction, int, int, error) {
+	parent := item.Type == NullMime
+	if parent {
+		item.Type, item.Value = node.Mime, node.Data
+	}
+	if node.Data == item.Value && node.Mime == item.Type && node.metaMatches(item.Meta) {
+		client.logf(LogInfo, "unchanged key: %+v\n", item.Key)
+		return NodeUnchanged, node.Version, node.Version, nil
+	}
+	if options.LastApplied != nil && !parent {
+		entry, ok := options.LastApplied.Get(item.Key)
+		if ok && !entry.Matches(node.Mime, node.Data) {
+			overwrite, err := resolveConflict(Conflict{
+				Key:       item.Key,
+				LiveType:  node.Mime,
+				LiveValue: node.Data,
+				NewType:   item.Type,
+				NewValue:  item.Value,
+			}, options)
 			if err != nil {
-				return err
+				return "", node.Version, 0, err
 			}
-			params["version"] = strconv.Itoa(response.Version)
-
-			log.Printf("update key: %+v\n", item.Key)
-
-			statusCode, result, err := client.request(item.Key, http.MethodPost, params)
-			log.Printf("POST status: %+v, result: %+v, err: %+v\n", statusCode, result, err)
-			if err != nil {
-				return err
+			if !overwrite {
+				client.logf(LogInfo, "kept key: %+v\n", item.Key)
+				return NodeKept, node.Version, node.Version, nil
 			}
-			return nil
 		}
+	}
 
-		if statusCode == http.StatusBadRequest && skipAlreadyExist {
-			return nil
-		}
+	client.logf(LogInfo, "update key: %+v\n", item.Key)
 
-		return err
+	version, err := client.setNode(ctx, item.Key, item.Type, item.Value, node.mergeMeta(item.Meta), node.Version, options.Comment)
+	if err != nil {
+		return "", node.Version, 0, err
 	}
-	return err
+	options.changed(Change{Key: item.Key, Action: PlanUpdate, Mime: node.Mime, Data: node.Data, Meta: node.Meta(), Version: version})
+	return NodeUpdated, node.Version, version, nil
 }
 
-// DeleteNode delete node
-func (client *OnlineConfClient) DeleteNode(key string, comment string) error {
+// DeleteNode delete node, returns false if the node doesn't exist
+func (client *OnlineConfClient) DeleteNode(ctx context.Context, key string, comment string) (bool, error) {
+	return client.deleteExistingNode(ctx, key, ImportOptions{Comment: comment})
+}
 
-	statusCode, result, err := client.request(key, http.MethodGet, nil)
-	if statusCode != http.StatusOK {
-		log.Printf("GET status: %+v, result: %+v, err: %+v\n", statusCode, result, err)
-		return nil
+// DeleteNodes delete nodes in order, missing nodes are skipped, deleted nodes are recorded to roll back,
+// stops on the first error
+func (client *OnlineConfClient) DeleteNodes(ctx context.Context, keys []string, options ImportOptions) error {
+	for _, key := range keys {
+		if options.stopped() {
+			return ErrInterrupted
+		}
+		start := time.Now()
+		deleted, err := client.deleteExistingNode(ctx, key, options)
+		action := NodeSkipped
+		if deleted {
+			action = NodeDeleted
+		}
+		if err != nil {
+			action = ""
+		}
+		options.report(key, action, 0, 0, start, err)
+		if err != nil {
+			return err
+		}
+		if options.LastApplied != nil {
+			options.LastApplied.Delete(key)
+		}
 	}
+	return nil
+}
+
+// deleteExistingNode delete node of the current version, returns false if the node doesn't exist
+func (client *OnlineConfClient) deleteExistingNode(ctx context.Context, key string, options ImportOptions) (bool, error) {
 
-	//{"name":"qwerty","path":"/revise/test/qwerty","data":"1,2,3,ayewr","mime":"application/x-list","summary":"","description":"","version":3,"mtime":"2023-07-09 00:50:29","num_children":0,"access_modified":false,"rw":true,"notification":"none","notification_modified":false,"children":[]},
-	var response OnlineConfResponse
-	err = json.Unmarshal([]byte(result), &response)
+	node, err := client.GetNode(ctx, key)
 	if err != nil {
-		return err
+		return false, err
 	}
-
-	params := map[string]string{
-		"version": strconv.Itoa(response.Version),
-		"comment": comment,
+	if node == nil {
+		client.logf(LogInfo, "GET key %s, exists: false\n", key)
+		return false, nil
 	}
 
-	log.Printf("delete key: %+v\n", key)
+	client.logf(LogInfo, "delete key: %+v\n", key)
 
-	statusCode, result, err = client.request(key, http.MethodDelete, params)
-	log.Printf("DELETE status: %+v, result: %+v, err: %+v\n", statusCode, result, err)
+	err = client.deleteNode(ctx, key, node.Version, options.Comment)
 	if err != nil {
-		return err
+		return false, err
 	}
-	if statusCode != http.StatusOK {
-		return fmt.Errorf("delete node failure...status: %v, result: %v", statusCode, result)
-	}
-	return err
+	options.changed(Change{Key: key, Action: PlanDelete, Mime: node.Mime, Data: node.Data, Meta: node.Meta()})
+	return true, nil
 }
 
 func (client *OnlineConfClient) request(
+	ctx context.Context,
+	requestURL string,
+	method string,
+	params map[string]string,
+) (int, string, error) {
+	attempts := 1
+	if method == http.MethodGet || method == http.MethodDelete {
+		attempts += client.retries
+	}
+
+	var (
+		statusCode int
+		result     string
+		err        error
+	)
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			backoff := client.retryBackoff << (attempt - 1)
+			client.logf(LogInfo, "retry request in %v, status: %v, err: %v\n", backoff, statusCode, err)
+			select {
+			case <-ctx.Done():
+				return 0, "", ctx.Err()
+			case <-time.After(backoff):
+			}
+		}
+
+		statusCode, result, err = client.doRequest(ctx, requestURL, method, params)
+		client.logf(LogDebug, "response status: %v, result: %s, err: %v\n", statusCode, client.redactor.Body(requestURL, result), err)
+		if err == nil && statusCode < http.StatusInternalServerError {
+			break
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return statusCode, result, err
+}
+
+func (client *OnlineConfClient) doRequest(
+	ctx context.Context,
 	requestURL string,
 	method string,
 	params map[string]string,
@@ -227,19 +357,17 @@ func (client *OnlineConfClient) request(
 		requestParams.Add(paramKey, paramValue)
 	}
 
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
-	// Prepare HTTP Client
-	httpClient := &http.Client{Transport: transport}
 	var reader io.Reader = nil
 	if method != http.MethodGet {
 		reader = strings.NewReader(requestParams.Encode())
 	}
 
-	url := fmt.Sprintf("%s/%s", client.host, requestURL)
+	url := client.host
+	if requestURL != "" {
+		url = fmt.Sprintf("%s/%s", client.host, requestURL)
+	}
 
-	req, err := http.NewRequest(method, url, reader)
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
 	if err != nil {
 		return 0, "", fmt.Errorf("can't create the http request...%s", err.Error())
 	}
@@ -254,10 +382,16 @@ func (client *OnlineConfClient) request(
 
 	}
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	if client.auth != nil {
+		err = client.auth.Authenticate(req)
+		if err != nil {
+			return 0, "", fmt.Errorf("can't authenticate the http request...%s", err.Error())
+		}
+	}
 
 	// Call the request
-	log.Printf("request url: %s\n", req.URL.String())
-	res, err := httpClient.Do(req)
+	client.logf(LogDebug, "request %s %s, params: %s\n", method, req.URL.Redacted(), client.redactor.Params(requestURL, params))
+	res, err := client.httpClient.Do(req)
 	if err != nil {
 		return 0, "", fmt.Errorf("can't call the http request...%s", err.Error())
 	}
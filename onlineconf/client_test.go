@@ -0,0 +1,209 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"onlineconf-yaml/onlineconf/onlineconftest"
+	"onlineconf-yaml/yml/parser"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateEmptyNode(t *testing.T) {
+
+	server := onlineconftest.NewServer()
+	defer server.Close()
+	server.SetNode("importConfig", "application/x-null", "")
+
+	client, err := NewOnlineConfClient(server.NodeURL("importConfig"), "", "")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	err = client.CreateEmptyNode(ctx, "fee", false, "init")
+	require.NoError(t, err)
+	assert.Equal(t, []onlineconftest.Version{{Version: 1, Mime: "application/x-null", Comment: "init"}}, server.Versions("importConfig/fee"))
+
+	err = client.CreateEmptyNode(ctx, "fee", false, "init")
+	assert.ErrorIs(t, err, ErrAlreadyExists)
+	err = client.CreateEmptyNode(ctx, "fee", true, "init")
+	assert.NoError(t, err)
+
+	err = client.CreateEmptyNode(ctx, "missing/fee", true, "init")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestCreateNode(t *testing.T) {
+
+	server := onlineconftest.NewServer()
+	defer server.Close()
+	server.SetNode("importConfig/fee", "application/x-null", "")
+
+	client, err := NewOnlineConfClient(server.NodeURL("importConfig"), "", "")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	item := parser.OnlineConfItem{Key: "fee/KEY1", Value: "4", Type: "text/plain"}
+	action, err := client.CreateNode(ctx, item, false, false, "")
+	require.NoError(t, err)
+	assert.Equal(t, NodeCreated, action)
+
+	_, err = client.CreateNode(ctx, item, false, false, "")
+	assert.ErrorIs(t, err, ErrAlreadyExists)
+
+	action, err = client.CreateNode(ctx, item, false, true, "")
+	require.NoError(t, err)
+	assert.Equal(t, NodeSkipped, action)
+
+	action, err = client.CreateNode(ctx, item, true, false, "")
+	require.NoError(t, err)
+	assert.Equal(t, NodeUnchanged, action)
+	assert.Len(t, server.Versions("importConfig/fee/KEY1"), 1)
+
+	item.Value = "5"
+	action, err = client.CreateNode(ctx, item, true, false, "update")
+	require.NoError(t, err)
+	assert.Equal(t, NodeUpdated, action)
+	assert.Equal(t, onlineconftest.Version{Version: 2, Data: "5", Mime: "text/plain", Comment: "update"}, server.Versions("importConfig/fee/KEY1")[1])
+
+	item.Type = "text/unknown"
+	_, err = client.CreateNode(ctx, item, true, true, "")
+	assert.ErrorIs(t, err, ErrValidation)
+}
+
+func TestCreateNodeGetFailure(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"AlreadyExists","message":"node already exists"}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client, err := NewOnlineConfClient(server.URL, "", "", WithRetries(0, time.Millisecond))
+	require.NoError(t, err)
+
+	item := parser.OnlineConfItem{Key: "fee/KEY1", Value: "4", Type: "text/plain"}
+	action, err := client.CreateNode(context.Background(), item, true, false, "")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrAlreadyExists)
+	assert.Equal(t, NodeAction(""), action)
+}
+
+func TestDeleteNode(t *testing.T) {
+
+	server := onlineconftest.NewServer()
+	defer server.Close()
+	server.SetNode("importConfig/fee/KEY1", "text/plain", "4")
+	server.SetNode("importConfig/fee/KEY1", "text/plain", "5")
+	server.SetNode("importConfig/fee/KEY2", "text/plain", "5")
+	server.Deny("importConfig/fee/KEY2")
+
+	client, err := NewOnlineConfClient(server.NodeURL("importConfig"), "", "")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	deleted, err := client.DeleteNode(ctx, "fee/KEY1", "")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	assert.Nil(t, server.Versions("importConfig/fee/KEY1"))
+
+	deleted, err = client.DeleteNode(ctx, "fee/KEY1", "")
+	assert.NoError(t, err)
+	assert.False(t, deleted)
+
+	deleted, err = client.DeleteNode(ctx, "fee/KEY2", "")
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.False(t, deleted)
+	assert.Equal(t, []string{"/importConfig", "/importConfig/fee", "/importConfig/fee/KEY2"}, server.Paths())
+}
+
+func TestResponseError(t *testing.T) {
+
+	cases := []struct {
+		statusCode int
+		body       string
+		expected   error
+	}{
+		{http.StatusBadRequest, `{"error":"AlreadyExists","message":"/fee"}`, ErrAlreadyExists},
+		{http.StatusBadRequest, `{"error":"VersionNotMatch"}`, ErrVersionConflict},
+		{http.StatusNotFound, `{"error":"ParentNotFound"}`, ErrNotFound},
+		{http.StatusForbidden, `<html>Forbidden</html>`, ErrForbidden},
+		{http.StatusBadRequest, `{"error":"Unknown"}`, ErrValidation},
+	}
+	for _, c := range cases {
+		assert.ErrorIs(t, newResponseError(c.statusCode, c.body), c.expected, c.body)
+	}
+	assert.Nil(t, newResponseError(http.StatusBadGateway, "").Unwrap())
+}
+
+func TestRequestRetries(t *testing.T) {
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"name":"KEY1","path":"/importConfig/KEY1","data":"4","mime":"text/plain","version":2}`))
+	}))
+	defer server.Close()
+
+	client, err := NewOnlineConfClient(server.URL, "", "", WithRetries(2, time.Millisecond))
+	require.NoError(t, err)
+
+	node, err := client.GetNode(context.Background(), "KEY1")
+	require.NoError(t, err)
+	assert.Equal(t, "4", node.Data)
+	assert.Equal(t, 2, node.Version)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	// POST is not retried
+	atomic.StoreInt32(&calls, 0)
+	_, err = client.setNode(context.Background(), "KEY1", "text/plain", "5", parser.NodeMeta{}, 2, "")
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// retries are exhausted
+	atomic.StoreInt32(&calls, -10)
+	_, err = client.GetNode(context.Background(), "KEY1")
+	assert.Error(t, err)
+	assert.Equal(t, int32(-7), atomic.LoadInt32(&calls))
+}
+
+func TestRequestTimeout(t *testing.T) {
+
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	client, err := NewOnlineConfClient(server.URL, "", "", WithTimeout(50*time.Millisecond), WithRetries(0, 0))
+	require.NoError(t, err)
+
+	_, err = client.GetNode(context.Background(), "KEY1")
+	assert.Error(t, err)
+
+	client, err = NewOnlineConfClient(server.URL, "", "", WithTimeout(0), WithRetries(5, time.Second))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = client.GetNode(ctx, "KEY1")
+	assert.Error(t, err)
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+}
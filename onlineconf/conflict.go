@@ -0,0 +1,57 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrConflict node was changed outside of the import since the last applied value
+var ErrConflict = errors.New("node was changed outside of the import")
+
+// ConflictPolicy resolution of the node changed outside of the import
+type ConflictPolicy string
+
+// conflict policies
+const (
+	// ConflictRefuse fail the import
+	ConflictRefuse ConflictPolicy = "refuse"
+	// ConflictOverwrite replace the live value by the parsed config
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictKeep keep the live value
+	ConflictKeep ConflictPolicy = "keep"
+	// ConflictPrompt ask ImportOptions.Prompt
+	ConflictPrompt ConflictPolicy = "prompt"
+)
+
+// Conflict live node value differs from both last applied and parsed config values
+type Conflict struct {
+	Key       string
+	LiveType  string
+	LiveValue string
+	NewType   string
+	NewValue  string
+}
+
+// ParseConflictPolicy parse policy name
+func ParseConflictPolicy(name string) (ConflictPolicy, error) {
+	switch policy := ConflictPolicy(name); policy {
+	case ConflictRefuse, ConflictOverwrite, ConflictKeep, ConflictPrompt:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown conflict policy '%s'", name)
+}
+
+// resolveConflict returns true if the live value should be overwritten
+func resolveConflict(conflict Conflict, options ImportOptions) (bool, error) {
+	switch options.ConflictPolicy {
+	case ConflictOverwrite:
+		return true, nil
+	case ConflictKeep:
+		return false, nil
+	case ConflictPrompt:
+		if options.Prompt != nil {
+			return options.Prompt(conflict)
+		}
+	}
+	return false, fmt.Errorf("key %s: %w", conflict.Key, ErrConflict)
+}
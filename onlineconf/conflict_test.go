@@ -0,0 +1,116 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"onlineconf-yaml/onlineconf/onlineconftest"
+	"onlineconf-yaml/state"
+	"onlineconf-yaml/yml/parser"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportConflict(t *testing.T) {
+
+	server := onlineconftest.NewServer()
+	defer server.Close()
+	server.SetNode("importConfig", "application/x-null", "")
+
+	client, err := NewOnlineConfClient(server.NodeURL("importConfig"), "", "")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	config := map[string]parser.OnlineConfItem{
+		"fee/KEY1": {Key: "fee/KEY1", Value: "4", Type: "text/plain"},
+		"fee/KEY2": {Key: "fee/KEY2", Value: "4", Type: "text/plain"},
+	}
+	lastApplied := state.New("importConfig")
+	options := ImportOptions{UpdateIfExists: true, SkipAlreadyExist: true, LastApplied: lastApplied}
+
+	stats, err := client.Import(ctx, config, options)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats[NodeCreated])
+
+	// operator edits the node, config changes too
+	server.SetNode("importConfig/fee/KEY1", "text/plain", "5")
+	config["fee/KEY1"] = parser.OnlineConfItem{Key: "fee/KEY1", Value: "6", Type: "text/plain"}
+	config["fee/KEY2"] = parser.OnlineConfItem{Key: "fee/KEY2", Value: "6", Type: "text/plain"}
+
+	_, err = client.Import(ctx, config, options)
+	assert.ErrorIs(t, err, ErrConflict)
+
+	options.ConflictPolicy = ConflictKeep
+	stats, err = client.Import(ctx, config, options)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats[NodeKept])
+	assert.Equal(t, 1, stats[NodeUpdated])
+	assert.Equal(t, "5", server.Versions("importConfig/fee/KEY1")[1].Data)
+
+	prompted := []Conflict{}
+	options.ConflictPolicy = ConflictPrompt
+	options.Prompt = func(conflict Conflict) (bool, error) {
+		prompted = append(prompted, conflict)
+		return true, nil
+	}
+	stats, err = client.Import(ctx, config, options)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats[NodeUpdated])
+	assert.Equal(t, []Conflict{{Key: "fee/KEY1", LiveType: "text/plain", LiveValue: "5", NewType: "text/plain", NewValue: "6"}}, prompted)
+
+	// overwritten value is the last applied one now
+	options.ConflictPolicy = ConflictRefuse
+	stats, err = client.Import(ctx, config, options)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats[NodeUnchanged])
+}
+
+func TestApplyPlanConflict(t *testing.T) {
+
+	server := onlineconftest.NewServer()
+	defer server.Close()
+	server.SetNode("importConfig/fee/KEY1", "text/plain", "5")
+	server.SetNode("importConfig/fee/OLD", "text/plain", "2")
+
+	client, err := NewOnlineConfClient(server.NodeURL("importConfig"), "", "")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// operator edited both nodes after the last import
+	lastApplied := state.New("importConfig")
+	lastApplied.Set("fee/KEY1", "text/plain", "1", 1)
+	lastApplied.Set("fee/OLD", "text/plain", "1", 1)
+	config := map[string]parser.OnlineConfItem{
+		"fee/KEY1": {Key: "fee/KEY1", Value: "6", Type: "text/plain"},
+	}
+	plan, err := client.GetPlan(ctx, "importConfig", config)
+	require.NoError(t, err)
+	plan.MarkDrifted(lastApplied)
+
+	versions := len(server.Versions("importConfig/fee/KEY1"))
+	options := ImportOptions{LastApplied: lastApplied}
+	assert.ErrorIs(t, client.ApplyPlan(ctx, plan, options), ErrConflict)
+	assert.Len(t, server.Versions("importConfig/fee/KEY1"), versions)
+
+	options.ConflictPolicy = ConflictKeep
+	options.Report = NewReport("importConfig")
+	require.NoError(t, client.ApplyPlan(ctx, plan, options))
+	assert.Len(t, server.Versions("importConfig/fee/KEY1"), versions)
+	assert.Contains(t, server.Paths(), "/importConfig/fee/OLD")
+	assert.Equal(t, 2, options.Report.Summary[string(NodeKept)])
+
+	prompted := []Conflict{}
+	options.ConflictPolicy = ConflictPrompt
+	options.Prompt = func(conflict Conflict) (bool, error) {
+		prompted = append(prompted, conflict)
+		return true, nil
+	}
+	require.NoError(t, client.ApplyPlan(ctx, plan, options))
+	assert.Equal(t, []Conflict{
+		{Key: "fee/KEY1", LiveType: "text/plain", LiveValue: "5", NewType: "text/plain", NewValue: "6"},
+		{Key: "fee/OLD", LiveType: "text/plain", LiveValue: "2"},
+	}, prompted)
+	assert.Equal(t, "6", server.Versions("importConfig/fee/KEY1")[versions].Data)
+	assert.NotContains(t, server.Paths(), "/importConfig/fee/OLD")
+}
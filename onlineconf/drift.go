@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"onlineconf-yaml/yml/parser"
+	"time"
+)
+
+// DriftKind difference between the config and the live node
+type DriftKind string
+
+// drift kinds
+const (
+	// DriftMissing node is in the config, but not in OnlineConf
+	DriftMissing DriftKind = "missing"
+	// DriftChanged node data, mime or metadata differs from the config
+	DriftChanged DriftKind = "changed"
+	// DriftExtra node is in OnlineConf, but not in the config
+	DriftExtra DriftKind = "extra"
+)
+
+// DriftItem node which differs from the config
+type DriftItem struct {
+	Key       string    `json:"key"`
+	Kind      DriftKind `json:"kind"`
+	Type      string    `json:"type,omitempty"`
+	Value     string    `json:"value,omitempty"`
+	LiveType  string    `json:"live_type,omitempty"`
+	LiveValue string    `json:"live_value,omitempty"`
+	// Meta metadata of the config, LiveMeta metadata of the node, nil if empty
+	Meta        *parser.NodeMeta `json:"meta,omitempty"`
+	LiveMeta    *parser.NodeMeta `json:"live_meta,omitempty"`
+	LiveVersion int              `json:"live_version,omitempty"`
+}
+
+// DriftReport differences between the config and the OnlineConf subtree
+type DriftReport struct {
+	Root    string         `json:"root"`
+	Checked time.Time      `json:"checked"`
+	Drifted bool           `json:"drifted"`
+	Summary map[string]int `json:"summary"`
+	Items   []DriftItem    `json:"items"`
+}
+
+// NewDriftReport differences of the plan, protected nodes are not reported
+func NewDriftReport(plan *Plan) *DriftReport {
+	report := &DriftReport{
+		Root:    plan.Root,
+		Checked: time.Now().UTC().Truncate(time.Second),
+		Summary: map[string]int{string(DriftMissing): 0, string(DriftChanged): 0, string(DriftExtra): 0},
+		Items:   []DriftItem{},
+	}
+	for _, item := range plan.Items {
+		var driftItem DriftItem
+		switch item.Action {
+		case PlanCreate:
+			driftItem = DriftItem{Key: item.Key, Kind: DriftMissing, Type: item.Type, Value: item.Value, Meta: item.Meta}
+		case PlanUpdate:
+			driftItem = DriftItem{Key: item.Key, Kind: DriftChanged, Type: item.Type, Value: item.Value, Meta: item.Meta,
+				LiveType: item.OldType, LiveValue: item.OldValue, LiveMeta: item.OldMeta, LiveVersion: item.Version}
+		case PlanDelete:
+			driftItem = DriftItem{Key: item.Key, Kind: DriftExtra,
+				LiveType: item.OldType, LiveValue: item.OldValue, LiveMeta: item.OldMeta, LiveVersion: item.Version}
+		default:
+			continue
+		}
+		report.Items = append(report.Items, driftItem)
+		report.Summary[string(driftItem.Kind)]++
+	}
+	report.Drifted = len(report.Items) > 0
+	return report
+}
+
+// GetDrift compare the parsed config with the live subtree, nothing is changed,
+// nodes matching ignored patterns and their descendants are not reported
+func (client *OnlineConfClient) GetDrift(ctx context.Context, root string, config map[string]parser.OnlineConfItem, ignored []string) (*DriftReport, error) {
+	plan, err := client.GetPlan(ctx, root, config)
+	if err != nil {
+		return nil, err
+	}
+	items := []PlanItem{}
+	for _, item := range plan.Items {
+		ignore, err := isProtected(item.Key, ignored)
+		if err != nil {
+			return nil, err
+		}
+		if !ignore {
+			items = append(items, item)
+		}
+	}
+	plan.Items = items
+	return NewDriftReport(plan), nil
+}
+
+// Write write report as JSON
+func (report *DriftReport) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
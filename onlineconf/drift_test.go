@@ -0,0 +1,64 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"onlineconf-yaml/onlineconf/onlineconftest"
+	"onlineconf-yaml/yml/parser"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDrift(t *testing.T) {
+
+	server := onlineconftest.NewServer()
+	defer server.Close()
+	server.SetNode("importConfig/fee/KEY1", "text/plain", "1")
+	server.SetNode("importConfig/fee/KEY2", "text/plain", "manual")
+	server.SetNode("importConfig/fee/EXTRA", "text/plain", "3")
+	server.SetNode("importConfig/manual/KEY", "text/plain", "4")
+
+	client, err := NewOnlineConfClient(server.NodeURL("importConfig"), "", "")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	config := map[string]parser.OnlineConfItem{
+		"fee/KEY1": {Key: "fee/KEY1", Value: "1", Type: "text/plain", Meta: parser.NodeMeta{Summary: "first"}},
+		"fee/KEY2": {Key: "fee/KEY2", Value: "2", Type: "text/plain"},
+		"fee/KEY3": {Key: "fee/KEY3", Value: "3", Type: "text/plain"},
+	}
+	report, err := client.GetDrift(ctx, "importConfig", config, []string{"manual"})
+	require.NoError(t, err)
+	assert.True(t, report.Drifted)
+	assert.Equal(t, map[string]int{"missing": 1, "changed": 2, "extra": 1}, report.Summary)
+	assert.Equal(t, []DriftItem{
+		{Key: "fee/KEY1", Kind: DriftChanged, Type: "text/plain", Value: "1", Meta: &parser.NodeMeta{Summary: "first"},
+			LiveType: "text/plain", LiveValue: "1", LiveVersion: 2},
+		{Key: "fee/KEY2", Kind: DriftChanged, Type: "text/plain", Value: "2", LiveType: "text/plain", LiveValue: "manual", LiveVersion: 2},
+		{Key: "fee/KEY3", Kind: DriftMissing, Type: "text/plain", Value: "3"},
+		{Key: "fee/EXTRA", Kind: DriftExtra, LiveType: "text/plain", LiveValue: "3", LiveVersion: 2},
+	}, report.Items)
+	assert.Equal(t, 0, server.Requests("POST")+server.Requests("DELETE"))
+
+	var buf bytes.Buffer
+	require.NoError(t, report.Write(&buf))
+	var decoded DriftReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, report.Items, decoded.Items)
+
+	config["fee/KEY1"] = parser.OnlineConfItem{Key: "fee/KEY1", Value: "1", Type: "text/plain"}
+	config["fee/KEY2"] = parser.OnlineConfItem{Key: "fee/KEY2", Value: "manual", Type: "text/plain"}
+	config["fee/EXTRA"] = parser.OnlineConfItem{Key: "fee/EXTRA", Value: "3", Type: "text/plain"}
+	server.SetNode("importConfig/fee/KEY3", "text/plain", "3")
+	report, err = client.GetDrift(ctx, "importConfig", config, []string{"manual"})
+	require.NoError(t, err)
+	assert.False(t, report.Drifted)
+	assert.Empty(t, report.Items)
+
+	_, err = client.GetDrift(ctx, "importConfig", config, []string{"["})
+	assert.Error(t, err)
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// errors of the onlineconf responses, use errors.Is to check
+var (
+	ErrAlreadyExists   = errors.New("node already exists")
+	ErrNotFound        = errors.New("node not found")
+	ErrVersionConflict = errors.New("node version conflict")
+	ErrForbidden       = errors.New("access denied")
+	ErrValidation      = errors.New("invalid request")
+)
+
+// ErrInterrupted import is stopped before all operations are done
+var ErrInterrupted = errors.New("import interrupted")
+
+var responseErrors = map[string]error{
+	"AlreadyExists":   ErrAlreadyExists,
+	"NotFound":        ErrNotFound,
+	"ParentNotFound":  ErrNotFound,
+	"VersionNotMatch": ErrVersionConflict,
+	"AccessDenied":    ErrForbidden,
+	"InvalidValue":    ErrValidation,
+	"CommentRequired": ErrValidation,
+	"NotEmpty":        ErrValidation,
+}
+
+// ResponseError onlineconf error response
+type ResponseError struct {
+	StatusCode int
+	Response   OnlineConfResponse
+	Body       string
+}
+
+// newResponseError parse error response
+func newResponseError(statusCode int, body string) *ResponseError {
+	err := &ResponseError{
+		StatusCode: statusCode,
+		Body:       body,
+	}
+	// body is not always json, e.g. from proxy
+	json.Unmarshal([]byte(body), &err.Response)
+	return err
+}
+
+func (err *ResponseError) Error() string {
+	if err.Response.Error == "" {
+		return fmt.Sprintf("status: %v, result: %v", err.StatusCode, err.Body)
+	}
+	return fmt.Sprintf("status: %v, error: %v, message: %v", err.StatusCode, err.Response.Error, err.Response.Message)
+}
+
+// Unwrap sentinel error of the response error kind or status
+func (err *ResponseError) Unwrap() error {
+	if kind, ok := responseErrors[err.Response.Error]; ok {
+		return kind
+	}
+	switch err.StatusCode {
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusConflict:
+		return ErrVersionConflict
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrForbidden
+	case http.StatusBadRequest:
+		return ErrValidation
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"onlineconf-yaml/yml/parser"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// ExportYML read subtree and build yml document, parser.WalkByYMLNode flattens it back to the same nodes
+func (client *OnlineConfClient) ExportYML(ctx context.Context, key string) ([]byte, error) {
+	node, err := client.GetTree(ctx, key, -1)
+	if err != nil {
+		return nil, err
+	}
+	if node == nil {
+		return nil, fmt.Errorf("node '%s' not found", key)
+	}
+
+	data, err := NodeToYML(node)
+	if err != nil {
+		return nil, err
+	}
+	return encodeYML(data)
+}
+
+// NodeToYML convert node to yml node: nodes with children to maps, application/x-yaml values inlined as written, other values to scalars,
+// metadata is exported to the _meta key, value of the leaf with metadata to the _value key
+func NodeToYML(node *Node) (*yamlv3.Node, error) {
+	meta := metaToYML(node.Meta())
+	if len(node.Children) > 0 {
+		data := &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+		if len(meta.Content) > 0 {
+			data.Content = append(data.Content, stringNode(parser.MetaKey), meta)
+		}
+		for i := range node.Children {
+			value, err := NodeToYML(&node.Children[i])
+			if err != nil {
+				return nil, err
+			}
+			data.Content = append(data.Content, stringNode(node.Children[i].Name), value)
+		}
+		return data, nil
+	}
+
+	value, err := leafToYML(node)
+	if err != nil || len(meta.Content) == 0 {
+		return value, err
+	}
+	return &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map", Content: []*yamlv3.Node{
+		stringNode(parser.ValueKey), value,
+		stringNode(parser.MetaKey), meta,
+	}}, nil
+}
+
+// leafToYML yml node of the leaf value, application/x-yaml values keep the scalar text, quoting and key order
+func leafToYML(node *Node) (*yamlv3.Node, error) {
+	switch node.Mime {
+	case NullMime:
+		return nullNode(), nil
+	case "application/x-yaml":
+		var document yamlv3.Node
+		err := yamlv3.Unmarshal([]byte(node.Data), &document)
+		if err != nil {
+			return nil, fmt.Errorf("can't unmarshal yaml value of the '%s'... %s", node.Path, err.Error())
+		}
+		if document.Kind != yamlv3.DocumentNode || len(document.Content) == 0 {
+			return nullNode(), nil
+		}
+		return document.Content[0], nil
+	case "text/plain":
+	default:
+		log.Printf("WARNING: %s value of the '%s' is exported as text/plain\n", node.Mime, node.Path)
+	}
+	return stringNode(node.Data), nil
+}
+
+// metaToYML metadata map without empty fields
+func metaToYML(meta parser.NodeMeta) *yamlv3.Node {
+	data := &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+	fields := [][2]string{
+		{"summary", meta.Summary},
+		{"description", meta.Description},
+		{"notification", meta.Notification},
+	}
+	for _, field := range fields {
+		if field[1] != "" {
+			data.Content = append(data.Content, stringNode(field[0]), stringNode(field[1]))
+		}
+	}
+	return data
+}
+
+// stringNode string scalar, quoted by the encoder if the plain text would be read as another type
+func stringNode(value string) *yamlv3.Node {
+	return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: value}
+}
+
+func nullNode() *yamlv3.Node {
+	return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!null", Value: "null"}
+}
+
+// encodeYML yml text of the node with 2 spaces indent
+func encodeYML(node *yamlv3.Node) ([]byte, error) {
+	var text strings.Builder
+	encoder := yamlv3.NewEncoder(&text)
+	encoder.SetIndent(2)
+	err := encoder.Encode(node)
+	if err != nil {
+		return nil, err
+	}
+	err = encoder.Close()
+	return []byte(text.String()), err
+}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"testing"
+
+	"onlineconf-yaml/yml/parser"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeToYML(t *testing.T) {
+
+	cfgFilepath, err := writeYMLConfig(`
+fee:
+  common:
+    R:
+      "1001-1001-1001":
+        KEY1:
+          - fee: 4
+            pmtype: FEEKEY1
+            subject: "Simple fee"
+  volatile:
+    VR:
+      "1001-1001-1001":
+        KEY1:
+          - fee: 4
+            pmtype: FEEKEY1
+            subject: "Simple fee"
+      "1006-1001-1001":
+        KEY3:
+          - fee: 2.15
+            pmtype: FEEKEY3
+            subject: "Simple fee"
+        KEY2:
+          - fee: 2.15
+            pmtype: FEEKEY2
+            subject: "Simple fee"
+  parent_nested:
+    sub_parent01: {}
+    reg-exp-.*?@part01\.part02\.part03\.ru: {}
+scalars:
+  int: 10
+  float: 2.15
+  bool: true
+  string: "true"
+  multiline: |
+    first line
+    second line
+  list:
+    - 1
+    - two
+  numbers:
+    - 1.10
+    - 0x1F
+    - "2.0"
+    - ~
+  records:
+    - subject: "Simple fee"
+      fee: 2.150
+  quoted: "1.10"
+  empty: ""
+meta:
+  _meta:
+    summary: Fees
+  KEY1:
+    _value: 4
+    _meta:
+      description: Fee of the KEY1
+      notification: with-value
+  KEY2: 2
+`)
+	require.NoError(t, err)
+
+	layers, err := parser.GetYMLLayers([]string{cfgFilepath}, parser.DocumentsMerge, false)
+	require.NoError(t, err)
+	expected := parser.WalkByYMLNode(layers.Node, "", false)
+	require.Equal(t, "Fees", expected["meta"].Meta.Summary)
+	require.Equal(t, "- 1.10\n- 0x1F\n- \"2.0\"\n- ~", expected["scalars/numbers"].Value)
+
+	exported, err := NodeToYML(buildNode(expected))
+	require.NoError(t, err)
+	content, err := encodeYML(exported)
+	require.NoError(t, err)
+
+	cfgFilepath, err = writeYMLConfig(string(content))
+	require.NoError(t, err)
+	layers, err = parser.GetYMLLayers([]string{cfgFilepath}, parser.DocumentsMerge, false)
+	require.NoError(t, err)
+	src := parser.WalkByYMLNode(layers.Node, "", false)
+
+	assert.Equal(t, expected, src)
+}
+
+// buildNode store parsed config like OnlineConf does
+func buildNode(config map[string]parser.OnlineConfItem) *Node {
+	root := &Node{Mime: NullMime}
+	keys := []string{}
+	for k := range config {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		node := root
+		for _, name := range strings.Split(key, "/") {
+			var child *Node
+			for i := range node.Children {
+				if node.Children[i].Name == name {
+					child = &node.Children[i]
+				}
+			}
+			if child == nil {
+				node.Children = append(node.Children, Node{
+					Name: name,
+					Path: node.Path + "/" + name,
+					Mime: NullMime,
+				})
+				child = &node.Children[len(node.Children)-1]
+			}
+			node = child
+		}
+		node.Data = config[key].Value
+		node.Mime = config[key].Type
+		node.Summary = config[key].Meta.Summary
+		node.Description = config[key].Meta.Description
+		node.Notification = config[key].Meta.Notification
+		node.NotificationModified = config[key].Meta.Notification != ""
+	}
+	return root
+}
+
+func writeYMLConfig(content string) (string, error) {
+	f, err := ioutil.TempFile("", "testOnlineConf")
+	if err != nil {
+		return "", err
+	}
+	_, err = io.WriteString(f, content)
+	if err != nil {
+		return "", err
+	}
+	return f.Name(), err
+}
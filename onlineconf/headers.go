@@ -0,0 +1,223 @@
+package client
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/textproto"
+	"os"
+	"strings"
+)
+
+// headers managed by the http client, they are never taken from the headers file,
+// Authority is HTTP/2 pseudo header copied by browsers without colon
+var skippedHeaders = map[string]bool{
+	"Accept-Encoding":   true,
+	"Authority":         true,
+	"Connection":        true,
+	"Content-Length":    true,
+	"Content-Type":      true,
+	"Host":              true,
+	"Transfer-Encoding": true,
+}
+
+// GetHeaders return headers of the file with raw http headers or "Copy as cURL" command from browser devtools
+func (client *OnlineConfClient) GetHeaders(filepath string) (map[string]string, error) {
+
+	headers := map[string]string{}
+	if filepath == "" {
+		return headers, nil
+	}
+
+	content, err := os.ReadFile(filepath)
+	if err != nil {
+		return headers, err
+	}
+	return ParseHeaders(string(content))
+}
+
+// ParseHeaders parse raw http headers: the name is split by the first colon, lines starting with a space
+// continue the previous header, # starts a comment, duplicate headers are joined by comma and cookies by semicolon;
+// content starting with curl is parsed as "Copy as cURL" command
+func ParseHeaders(content string) (map[string]string, error) {
+	content = strings.TrimPrefix(content, "\ufeff")
+	if strings.HasPrefix(strings.TrimSpace(content), "curl ") {
+		return parseCurlHeaders(content)
+	}
+
+	lines := []string{}
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, "\r")
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+			continue
+		case (line[0] == ' ' || line[0] == '\t') && len(lines) > 0:
+			lines[len(lines)-1] += " " + trimmed
+		default:
+			lines = append(lines, trimmed)
+		}
+	}
+
+	headers := map[string]string{}
+	for _, line := range lines {
+		addHeaderLine(headers, line)
+	}
+	return headers, nil
+}
+
+// addHeaderLine add "Name: value" line, request line and HTTP/2 pseudo headers are skipped
+func addHeaderLine(headers map[string]string, line string) {
+	i := strings.Index(line, ":")
+	if i <= 0 {
+		return
+	}
+	name := strings.TrimSpace(line[:i])
+	if strings.ContainsAny(name, " \t") {
+		return
+	}
+	addHeader(headers, name, strings.TrimSpace(line[i+1:]))
+}
+
+func addHeader(headers map[string]string, name string, value string) {
+	name = textproto.CanonicalMIMEHeaderKey(name)
+	if skippedHeaders[name] {
+		return
+	}
+	previous, ok := headers[name]
+	switch {
+	case !ok || previous == "":
+		headers[name] = value
+	case value == "":
+	case name == "Cookie":
+		headers[name] = previous + "; " + value
+	default:
+		headers[name] = previous + ", " + value
+	}
+}
+
+// parseCurlHeaders headers, cookies and user of the curl command
+func parseCurlHeaders(command string) (map[string]string, error) {
+	args, err := splitShellWords(command)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := map[string]string{}
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		option, value, inline := splitCurlOption(arg)
+		switch option {
+		case "-H", "--header", "-b", "--cookie", "-A", "--user-agent", "-u", "--user", "-e", "--referer":
+		default:
+			continue
+		}
+		if !inline {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("bad curl command... %s value is missing", option)
+			}
+			i++
+			value = args[i]
+		}
+
+		switch option {
+		case "-H", "--header":
+			addHeaderLine(headers, value)
+		case "-b", "--cookie":
+			addHeader(headers, "Cookie", value)
+		case "-A", "--user-agent":
+			addHeader(headers, "User-Agent", value)
+		case "-e", "--referer":
+			addHeader(headers, "Referer", value)
+		case "-u", "--user":
+			headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(value))
+		}
+	}
+	return headers, nil
+}
+
+// splitCurlOption split --option=value and -Hvalue forms
+func splitCurlOption(arg string) (string, string, bool) {
+	if strings.HasPrefix(arg, "--") {
+		if i := strings.Index(arg, "="); i > 0 {
+			return arg[:i], arg[i+1:], true
+		}
+		return arg, "", false
+	}
+	if strings.HasPrefix(arg, "-") && len(arg) > 2 {
+		return arg[:2], arg[2:], true
+	}
+	return arg, "", false
+}
+
+// splitShellWords split the command by the shell rules: single and double quotes, $'...' strings,
+// backslash escapes and line continuations
+func splitShellWords(command string) ([]string, error) {
+	words := []string{}
+	var (
+		word    strings.Builder
+		inWord  bool
+		runes   = []rune(command)
+		escapes = map[rune]rune{'n': '\n', 't': '\t', 'r': '\r', '\\': '\\', '\'': '\'', '"': '"'}
+	)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\':
+			i++
+			if i < len(runes) && runes[i] != '\n' && runes[i] != '\r' {
+				word.WriteRune(runes[i])
+				inWord = true
+			} else if i+1 < len(runes) && runes[i] == '\r' && runes[i+1] == '\n' {
+				i++
+			}
+		case r == '\'':
+			inWord = true
+			for i++; i < len(runes) && runes[i] != '\''; i++ {
+				word.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("bad curl command... unterminated quote")
+			}
+		case r == '$' && i+1 < len(runes) && runes[i+1] == '\'':
+			inWord = true
+			for i += 2; i < len(runes) && runes[i] != '\''; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+					if escaped, ok := escapes[runes[i]]; ok {
+						word.WriteRune(escaped)
+						continue
+					}
+					word.WriteRune('\\')
+				}
+				word.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("bad curl command... unterminated quote")
+			}
+		case r == '"':
+			inWord = true
+			for i++; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune("\"\\$`", runes[i+1]) {
+					i++
+				}
+				word.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("bad curl command... unterminated quote")
+			}
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
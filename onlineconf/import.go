@@ -0,0 +1,233 @@
+package client
+
+import (
+	"context"
+	"onlineconf-yaml/journal"
+	"onlineconf-yaml/state"
+	"onlineconf-yaml/yml/parser"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ImportOptions options of the parsed config import
+type ImportOptions struct {
+	UpdateIfExists   bool
+	SkipAlreadyExist bool
+	SkipCreateNode   bool
+	Comment          string
+	// Concurrency number of parallel requests, 1 if not set
+	Concurrency int
+	// LastApplied values applied by previous imports, updated by the import, conflicts are not detected if nil
+	LastApplied *state.State
+	// TrustLastApplied skip requests for nodes whose last applied value equals the parsed config
+	TrustLastApplied bool
+	// ConflictPolicy resolution of nodes changed outside of the import, refuse if not set
+	ConflictPolicy ConflictPolicy
+	// Prompt ask whether to overwrite the node with ConflictPrompt policy
+	Prompt func(conflict Conflict) (bool, error)
+	// Journal operations completed by the interrupted import are skipped, new ones are recorded, if set
+	Journal *journal.Journal
+	// Stop no new operations are started after the channel is closed, in-flight ones are finished
+	Stop <-chan struct{}
+	// Changes nodes changed by the import are recorded to roll back, if set
+	Changes *Changes
+	// Report result of every node is added to the report, if set
+	Report *Report
+}
+
+// ImportStats number of imported nodes by result
+type ImportStats map[NodeAction]int
+
+// Import create parent nodes level by level, then create leaves by concurrent workers,
+// stops on the first error after in-flight requests are finished
+func (client *OnlineConfClient) Import(ctx context.Context, config map[string]parser.OnlineConfItem, options ImportOptions) (ImportStats, error) {
+	stats := ImportStats{}
+
+	if !options.SkipCreateNode {
+		for _, level := range parentNodeLevels(config) {
+			err := runParallel(ctx, options.Stop, options.Concurrency, len(level), func(ctx context.Context, i int) error {
+				operation := "parent " + level[i]
+				if entry, ok := options.done(operation); ok {
+					options.resumed(level[i], NodeAction(entry.Result), entry.Version)
+					return nil
+				}
+				if item, ok := config[level[i]]; ok {
+					// parent node with metadata
+					action, err := client.createNode(ctx, item, options)
+					if err != nil {
+						return err
+					}
+					return options.record(operation, string(action), 0)
+				}
+				if options.TrustLastApplied && options.LastApplied != nil {
+					if _, ok := options.LastApplied.Get(level[i]); ok {
+						return nil
+					}
+				}
+				start := time.Now()
+				version, err := client.createEmptyNode(ctx, level[i], options.SkipAlreadyExist, options.Comment)
+				if err != nil {
+					options.report(level[i], "", 0, 0, start, err)
+					return err
+				}
+				action := NodeSkipped
+				if version != 0 {
+					action = NodeCreated
+					options.changed(Change{Key: level[i], Action: PlanCreate, Version: version})
+					if options.LastApplied != nil {
+						options.LastApplied.Set(level[i], NullMime, "", version)
+					}
+				}
+				options.report(level[i], action, 0, version, start, nil)
+				return options.record(operation, string(action), version)
+			})
+			if err != nil {
+				return stats, err
+			}
+		}
+	}
+
+	parents := map[string]bool{}
+	if !options.SkipCreateNode {
+		for _, key := range parser.GetParentNodeKeys(config) {
+			parents[key] = true
+		}
+	}
+	items := make([]parser.OnlineConfItem, 0, len(config))
+	for _, item := range config {
+		// parent nodes with metadata are created with their level
+		if !parents[item.Key] {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Key < items[j].Key })
+
+	var mu sync.Mutex
+	err := runParallel(ctx, options.Stop, options.Concurrency, len(items), func(ctx context.Context, i int) error {
+		operation := "node " + items[i].Key
+		action := NodeAction("")
+		if entry, ok := options.done(operation); ok {
+			action = NodeAction(entry.Result)
+			options.resumed(items[i].Key, action, entry.Version)
+		} else {
+			var err error
+			action, err = client.createNode(ctx, items[i], options)
+			if err != nil {
+				return err
+			}
+			err = options.record(operation, string(action), 0)
+			if err != nil {
+				return err
+			}
+		}
+		mu.Lock()
+		stats[action]++
+		mu.Unlock()
+		return nil
+	})
+	return stats, err
+}
+
+// done operation completed by the interrupted import
+func (options ImportOptions) done(operation string) (journal.Entry, bool) {
+	if options.Journal == nil {
+		return journal.Entry{}, false
+	}
+	return options.Journal.Done(operation)
+}
+
+// record completed operation in the journal
+func (options ImportOptions) record(operation string, result string, version int) error {
+	if options.Journal == nil {
+		return nil
+	}
+	return options.Journal.Record(operation, result, version)
+}
+
+// stopped import is asked to stop
+func (options ImportOptions) stopped() bool {
+	select {
+	case <-options.Stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// parentNodeLevels parent node keys grouped by depth, parents first
+func parentNodeLevels(config map[string]parser.OnlineConfItem) [][]string {
+	levels := [][]string{}
+	for _, key := range parser.GetParentNodeKeys(config) {
+		depth := strings.Count(key, "/")
+		for len(levels) <= depth {
+			levels = append(levels, []string{})
+		}
+		levels[depth] = append(levels[depth], key)
+	}
+	return levels
+}
+
+// runParallel call fn for 0..n-1 by concurrency workers, returns the first error,
+// no new calls are started after an error or stop, in-flight calls are finished
+func runParallel(ctx context.Context, stop <-chan struct{}, concurrency int, n int, fn func(ctx context.Context, i int) error) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	jobs := make(chan int)
+	failed := make(chan struct{})
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		stopped  bool
+	)
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				err := fn(ctx, i)
+				if err != nil {
+					once.Do(func() {
+						firstErr = err
+						close(failed)
+					})
+				}
+			}
+		}()
+	}
+
+loop:
+	for i := 0; i < n; i++ {
+		// stop has priority over the next job
+		select {
+		case <-stop:
+			stopped = true
+			break loop
+		default:
+		}
+		select {
+		case <-failed:
+			break loop
+		case <-stop:
+			stopped = true
+			break loop
+		case <-ctx.Done():
+			break loop
+		case jobs <- i:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	if stopped {
+		return ErrInterrupted
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"onlineconf-yaml/journal"
+	"onlineconf-yaml/onlineconf/onlineconftest"
+	"onlineconf-yaml/yml/parser"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImport(t *testing.T) {
+
+	var (
+		mu       sync.Mutex
+		created  = map[string]bool{}
+		inFlight int
+		maxFlow  int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/")
+
+		mu.Lock()
+		inFlight++
+		if inFlight > maxFlow {
+			maxFlow = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		defer mu.Unlock()
+		inFlight--
+
+		if i := strings.LastIndex(key, "/"); i >= 0 && !created[key[:i]] {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, `{"error":"ParentNotFound","message":"%s"}`, key)
+			return
+		}
+		if strings.HasSuffix(key, "FAIL") {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"error":"AccessDenied"}`)
+			return
+		}
+		created[key] = true
+		fmt.Fprint(w, `{}`)
+	}))
+	defer server.Close()
+
+	client, err := NewOnlineConfClient(server.URL, "", "")
+	require.NoError(t, err)
+
+	config := map[string]parser.OnlineConfItem{}
+	for i := 0; i < 50; i++ {
+		key := fmt.Sprintf("fee/level%d/sub%d/KEY%d", i%3, i%5, i)
+		config[key] = parser.OnlineConfItem{Key: key, Value: "1", Type: "text/plain"}
+	}
+
+	stats, err := client.Import(context.Background(), config, ImportOptions{Concurrency: 4})
+	require.NoError(t, err)
+	assert.Equal(t, 50, stats[NodeCreated])
+	assert.Equal(t, 50+len(parser.GetParentNodeKeys(config)), len(created))
+	assert.LessOrEqual(t, maxFlow, 4)
+	assert.Greater(t, maxFlow, 1)
+
+	config["fee/level0/FAIL"] = parser.OnlineConfItem{Key: "fee/level0/FAIL", Value: "1", Type: "text/plain"}
+	created = map[string]bool{}
+	stats, err = client.Import(context.Background(), config, ImportOptions{Concurrency: 4})
+	assert.Error(t, err)
+	assert.Less(t, stats[NodeCreated], 50)
+}
+
+func TestImportResume(t *testing.T) {
+
+	server := onlineconftest.NewServer()
+	defer server.Close()
+	server.SetNode("importConfig", "application/x-null", "")
+
+	client, err := NewOnlineConfClient(server.NodeURL("importConfig"), "", "")
+	require.NoError(t, err)
+
+	config := map[string]parser.OnlineConfItem{
+		"fee/KEY1": {Key: "fee/KEY1", Value: "1", Type: "text/plain"},
+		"fee/KEY2": {Key: "fee/KEY2", Value: "2", Type: "text/plain"},
+	}
+
+	stop := make(chan struct{})
+	close(stop)
+	_, err = client.Import(context.Background(), config, ImportOptions{Stop: stop})
+	assert.ErrorIs(t, err, ErrInterrupted)
+	assert.Equal(t, []string{"/importConfig"}, server.Paths())
+
+	journalFilepath := filepath.Join(t.TempDir(), "import.journal")
+	importJournal, err := journal.Open(journalFilepath, "importConfig", "config", false)
+	require.NoError(t, err)
+	require.NoError(t, importJournal.Record("parent fee", string(NodeCreated), 1))
+	require.NoError(t, importJournal.Record("node fee/KEY1", string(NodeCreated), 0))
+	require.NoError(t, importJournal.Close())
+
+	// fee is created by the interrupted import
+	server.SetNode("importConfig/fee", "application/x-null", "")
+	importJournal, err = journal.Open(journalFilepath, "importConfig", "config", true)
+	require.NoError(t, err)
+	defer importJournal.Close()
+
+	stats, err := client.Import(context.Background(), config, ImportOptions{Journal: importJournal})
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats[NodeCreated])
+	assert.Equal(t, []string{"/importConfig", "/importConfig/fee", "/importConfig/fee/KEY2"}, server.Paths())
+	assert.Equal(t, 3, importJournal.Len())
+}
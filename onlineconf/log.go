@@ -0,0 +1,40 @@
+package client
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// LogLevel verbosity of the client log
+type LogLevel int
+
+// log levels, request params and response bodies are logged only in debug
+const (
+	LogError LogLevel = iota
+	LogInfo
+	LogDebug
+)
+
+// DefaultLogLevel log level of the new client
+const DefaultLogLevel = LogInfo
+
+var logLevels = map[string]LogLevel{
+	"error": LogError,
+	"info":  LogInfo,
+	"debug": LogDebug,
+}
+
+// ParseLogLevel log level by name: error, info or debug
+func ParseLogLevel(name string) (LogLevel, error) {
+	if level, ok := logLevels[strings.ToLower(name)]; ok {
+		return level, nil
+	}
+	return 0, fmt.Errorf("unknown log level '%s'", name)
+}
+
+func (client *OnlineConfClient) logf(level LogLevel, format string, args ...interface{}) {
+	if level <= client.logLevel {
+		log.Printf(format, args...)
+	}
+}
@@ -0,0 +1,50 @@
+package client
+
+import "onlineconf-yaml/yml/parser"
+
+// Meta metadata of the node, inherited notification is not included
+func (node *Node) Meta() parser.NodeMeta {
+	meta := parser.NodeMeta{Summary: node.Summary, Description: node.Description}
+	if node.NotificationModified {
+		meta.Notification = node.Notification
+	}
+	return meta
+}
+
+// mergeMeta metadata to send with the update, fields not set in the config keep the live values
+func (node *Node) mergeMeta(meta parser.NodeMeta) parser.NodeMeta {
+	live := node.Meta()
+	if meta.Summary == "" {
+		meta.Summary = live.Summary
+	}
+	if meta.Description == "" {
+		meta.Description = live.Description
+	}
+	if meta.Notification == "" {
+		meta.Notification = live.Notification
+	}
+	return meta
+}
+
+// metaMatches fields set in the config equal the live ones
+func (node *Node) metaMatches(meta parser.NodeMeta) bool {
+	return (meta.Summary == "" || meta.Summary == node.Summary) &&
+		(meta.Description == "" || meta.Description == node.Description) &&
+		(meta.Notification == "" || meta.Notification == node.Notification)
+}
+
+// metaRef metadata to store in the plan, nil if empty
+func metaRef(meta parser.NodeMeta) *parser.NodeMeta {
+	if meta.IsEmpty() {
+		return nil
+	}
+	return &meta
+}
+
+// metaValue metadata of the plan, empty if nil
+func metaValue(meta *parser.NodeMeta) parser.NodeMeta {
+	if meta == nil {
+		return parser.NodeMeta{}
+	}
+	return *meta
+}
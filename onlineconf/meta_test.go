@@ -0,0 +1,82 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"onlineconf-yaml/onlineconf/onlineconftest"
+	"onlineconf-yaml/yml/parser"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportMeta(t *testing.T) {
+
+	server := onlineconftest.NewServer()
+	defer server.Close()
+	server.SetNode("importConfig", "application/x-null", "")
+
+	client, err := NewOnlineConfClient(server.NodeURL("importConfig"), "", "")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	config := map[string]parser.OnlineConfItem{
+		"fee": {Key: "fee", Type: NullMime, Meta: parser.NodeMeta{Summary: "Fees"}},
+		"fee/KEY1": {Key: "fee/KEY1", Value: "4", Type: "text/plain",
+			Meta: parser.NodeMeta{Description: "Fee of the KEY1", Notification: "with-value"}},
+		"fee/KEY2": {Key: "fee/KEY2", Value: "2", Type: "text/plain"},
+	}
+	options := ImportOptions{UpdateIfExists: true, SkipAlreadyExist: true}
+	stats, err := client.Import(ctx, config, options)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats[NodeCreated])
+
+	node, err := client.GetNode(ctx, "fee")
+	require.NoError(t, err)
+	assert.Equal(t, "Fees", node.Summary)
+	node, err = client.GetNode(ctx, "fee/KEY1")
+	require.NoError(t, err)
+	assert.Equal(t, parser.NodeMeta{Description: "Fee of the KEY1", Notification: "with-value"}, node.Meta())
+
+	stats, err = client.Import(ctx, config, options)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats[NodeUnchanged])
+
+	// fields not set in the config are kept
+	config["fee/KEY1"] = parser.OnlineConfItem{Key: "fee/KEY1", Value: "4", Type: "text/plain",
+		Meta: parser.NodeMeta{Summary: "KEY1"}}
+	stats, err = client.Import(ctx, config, options)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats[NodeUpdated])
+	node, err = client.GetNode(ctx, "fee/KEY1")
+	require.NoError(t, err)
+	assert.Equal(t, parser.NodeMeta{Summary: "KEY1", Description: "Fee of the KEY1", Notification: "with-value"}, node.Meta())
+	assert.Equal(t, "4", node.Data)
+
+	// changed metadata of the parent is planned and applied, its data is kept
+	config["fee"] = parser.OnlineConfItem{Key: "fee", Type: NullMime, Meta: parser.NodeMeta{Summary: "All fees"}}
+	plan, err := client.GetPlan(ctx, "importConfig", config)
+	require.NoError(t, err)
+	require.Equal(t, 1, plan.Count(PlanUpdate))
+	assert.Equal(t, "fee", plan.Items[0].Key)
+	assert.Equal(t, &parser.NodeMeta{Summary: "All fees"}, plan.Items[0].Meta)
+	assert.Equal(t, &parser.NodeMeta{Summary: "Fees"}, plan.Items[0].OldMeta)
+
+	var output bytes.Buffer
+	plan.Print(&output, false)
+	assert.Contains(t, output.String(), `summary: "Fees" -> "All fees"`)
+
+	options.Changes = &Changes{}
+	require.NoError(t, client.ApplyPlan(ctx, plan, options))
+	node, err = client.GetNode(ctx, "fee")
+	require.NoError(t, err)
+	assert.Equal(t, "All fees", node.Summary)
+	assert.Equal(t, NullMime, node.Mime)
+
+	require.NoError(t, client.Rollback(ctx, options.Changes, options))
+	node, err = client.GetNode(ctx, "fee")
+	require.NoError(t, err)
+	assert.Equal(t, "Fees", node.Summary)
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Node onlineconf node
+type Node struct {
+	Name                 string `json:"name"`
+	Path                 string `json:"path"`
+	Data                 string `json:"data"`
+	Mime                 string `json:"mime"`
+	Summary              string `json:"summary"`
+	Description          string `json:"description"`
+	Version              int    `json:"version"`
+	MTime                string `json:"mtime"`
+	NumChildren          int    `json:"num_children"`
+	AccessModified       bool   `json:"access_modified"`
+	RW                   bool   `json:"rw"`
+	Notification         string `json:"notification"`
+	NotificationModified bool   `json:"notification_modified"`
+	Children             []Node `json:"children"`
+}
+
+// GetNode getting node with its immediate children, returns nil if node does not exist
+func (client *OnlineConfClient) GetNode(ctx context.Context, key string) (*Node, error) {
+	statusCode, result, err := client.request(ctx, key, http.MethodGet, nil)
+	if err != nil {
+		return nil, err
+	}
+	if statusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("get node failure...%w", newResponseError(statusCode, result))
+	}
+
+	var node Node
+	err = json.Unmarshal([]byte(result), &node)
+	if err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+// ListChildren getting immediate children of the node, returns nil if node does not exist
+func (client *OnlineConfClient) ListChildren(ctx context.Context, key string) ([]Node, error) {
+	node, err := client.GetNode(ctx, key)
+	if err != nil || node == nil {
+		return nil, err
+	}
+	return node.Children, nil
+}
+
+// GetTree getting node with descendants up to the depth, negative depth means the whole subtree,
+// returns nil if node does not exist
+func (client *OnlineConfClient) GetTree(ctx context.Context, key string, depth int) (*Node, error) {
+	node, err := client.GetNode(ctx, key)
+	if err != nil || node == nil {
+		return node, err
+	}
+	if depth == 0 {
+		node.Children = nil
+		return node, nil
+	}
+
+	for i, child := range node.Children {
+		if child.NumChildren == 0 || depth == 1 {
+			continue
+		}
+		subtree, err := client.GetTree(ctx, joinKey(key, child.Name), depth-1)
+		if err != nil {
+			return nil, err
+		}
+		if subtree != nil {
+			node.Children[i] = *subtree
+		}
+	}
+	return node, nil
+}
+
+// Flatten returns node descendants by key relative to the node
+func (node *Node) Flatten() map[string]Node {
+	nodes := map[string]Node{}
+	flattenNode(node, "", nodes)
+	return nodes
+}
+
+func flattenNode(node *Node, prefix string, nodes map[string]Node) {
+	for _, child := range node.Children {
+		key := joinKey(prefix, child.Name)
+		nodes[key] = child
+		flattenNode(&child, key, nodes)
+	}
+}
+
+func joinKey(prefix string, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "/" + name
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"onlineconf-yaml/onlineconf/onlineconftest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListChildren(t *testing.T) {
+
+	server := onlineconftest.NewServer()
+	defer server.Close()
+	server.SetNode("importConfig/fee/KEY1", "text/plain", "1")
+	server.SetNode("importConfig/fee/common/R", "text/plain", "2")
+
+	client, err := NewOnlineConfClient(server.NodeURL("importConfig"), "", "")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	children, err := client.ListChildren(ctx, "fee")
+	require.NoError(t, err)
+	require.Len(t, children, 2)
+	assert.Equal(t, "KEY1", children[0].Name)
+	assert.Equal(t, "1", children[0].Data)
+	assert.Equal(t, "common", children[1].Name)
+	assert.Equal(t, 1, children[1].NumChildren)
+	assert.Nil(t, children[1].Children)
+
+	children, err = client.ListChildren(ctx, "fee/KEY1")
+	require.NoError(t, err)
+	assert.Empty(t, children)
+
+	children, err = client.ListChildren(ctx, "missing")
+	require.NoError(t, err)
+	assert.Nil(t, children)
+}
+
+func TestGetTree(t *testing.T) {
+
+	server := onlineconftest.NewServer()
+	defer server.Close()
+	server.SetNode("importConfig/fee/KEY1", "text/plain", "1")
+	server.SetNode("importConfig/fee/common/R", "text/plain", "2")
+	server.SetNode("importConfig/fee/common/VR/KEY2", "text/plain", "3")
+	server.SetNode("importConfig/other", "text/plain", "4")
+
+	client, err := NewOnlineConfClient(server.NodeURL("importConfig"), "", "")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	for _, test := range []struct {
+		depth int
+		keys  []string
+	}{
+		{depth: 0, keys: []string{}},
+		{depth: 1, keys: []string{"fee", "other"}},
+		{depth: 2, keys: []string{"fee", "fee/KEY1", "fee/common", "other"}},
+		{depth: 3, keys: []string{"fee", "fee/KEY1", "fee/common", "fee/common/R", "fee/common/VR", "other"}},
+		{depth: -1, keys: []string{"fee", "fee/KEY1", "fee/common", "fee/common/R", "fee/common/VR", "fee/common/VR/KEY2", "other"}},
+	} {
+		node, err := client.GetTree(ctx, "", test.depth)
+		require.NoError(t, err, test.depth)
+		assert.Equal(t, test.keys, flattenedKeys(node), test.depth)
+	}
+
+	node, err := client.GetTree(ctx, "fee/common", -1)
+	require.NoError(t, err)
+	assert.Equal(t, "/importConfig/fee/common", node.Path)
+	assert.Equal(t, []string{"R", "VR", "VR/KEY2"}, flattenedKeys(node))
+	assert.Equal(t, "3", node.Flatten()["VR/KEY2"].Data)
+
+	node, err = client.GetTree(ctx, "missing", -1)
+	require.NoError(t, err)
+	assert.Nil(t, node)
+}
+
+func flattenedKeys(node *Node) []string {
+	keys := []string{}
+	for key := range node.Flatten() {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
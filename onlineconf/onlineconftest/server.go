@@ -0,0 +1,375 @@
+// Package onlineconftest provides in-memory OnlineConf admin API server for tests
+package onlineconftest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// URLPrefix prefix of the config API
+const URLPrefix = "/config"
+
+// errors returned by the server in the error field
+const (
+	ErrAccessDenied    = "AccessDenied"
+	ErrAlreadyExists   = "AlreadyExists"
+	ErrInvalidValue    = "InvalidValue"
+	ErrNotFound        = "NotFound"
+	ErrParentNotFound  = "ParentNotFound"
+	ErrVersionNotMatch = "VersionNotMatch"
+)
+
+var mimes = map[string]bool{
+	"application/x-null":     true,
+	"text/plain":             true,
+	"application/json":       true,
+	"application/x-yaml":     true,
+	"application/x-template": true,
+	"application/x-symlink":  true,
+	"application/x-case":     true,
+	"application/x-list":     true,
+	"application/x-server":   true,
+	"application/x-server2":  true,
+}
+
+// Version single version of the node
+type Version struct {
+	Version int
+	Data    string
+	Mime    string
+	Comment string
+}
+
+type node struct {
+	name         string
+	summary      string
+	description  string
+	notification string
+	// notificationModified notification is set on the node, not inherited
+	notificationModified bool
+	readonly             bool
+	versions             []Version
+	children             map[string]*node
+}
+
+// Server in-memory OnlineConf admin API, root node always exists
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	root     *node
+	requests map[string]int
+}
+
+// NewServer start the server
+func NewServer() *Server {
+	server := &Server{
+		root:     newNode("", "application/x-null", "", ""),
+		requests: map[string]int{},
+	}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.handle))
+	return server
+}
+
+// NodeURL url of the node to use as OnlineConfClient host
+func (server *Server) NodeURL(path string) string {
+	return server.URL + URLPrefix + "/" + strings.Trim(path, "/")
+}
+
+// SetNode create or update node and its missing parents
+func (server *Server) SetNode(path string, mime string, data string) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	n := server.root
+	for _, name := range splitPath(path) {
+		child, ok := n.children[name]
+		if !ok {
+			child = newNode(name, "application/x-null", "", "")
+			n.children[name] = child
+		}
+		n = child
+	}
+	n.addVersion(mime, data, "")
+}
+
+// Deny make node read only
+func (server *Server) Deny(path string) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	if n := server.find(path); n != nil {
+		n.readonly = true
+	}
+}
+
+// Versions history of the node, nil if node does not exist
+func (server *Server) Versions(path string) []Version {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	n := server.find(path)
+	if n == nil {
+		return nil
+	}
+	return append([]Version{}, n.versions...)
+}
+
+// Paths all node paths, sorted
+func (server *Server) Paths() []string {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	paths := []string{}
+	var walk func(n *node, path string)
+	walk = func(n *node, path string) {
+		for name, child := range n.children {
+			childPath := path + "/" + name
+			paths = append(paths, childPath)
+			walk(child, childPath)
+		}
+	}
+	walk(server.root, "")
+	sort.Strings(paths)
+	return paths
+}
+
+// Requests number of requests by method
+func (server *Server) Requests(method string) int {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return server.requests[method]
+}
+
+func (server *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, URLPrefix) {
+		writeError(w, http.StatusNotFound, ErrNotFound, r.URL.Path)
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, URLPrefix)
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrInvalidValue, err.Error())
+		return
+	}
+	params, err := url.ParseQuery(string(body))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrInvalidValue, err.Error())
+		return
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.requests[r.Method]++
+
+	switch r.Method {
+	case http.MethodGet:
+		server.get(w, path)
+	case http.MethodPost:
+		server.post(w, path, params)
+	case http.MethodDelete:
+		server.delete(w, path, params)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (server *Server) get(w http.ResponseWriter, path string) {
+	n := server.find(path)
+	if n == nil {
+		writeError(w, http.StatusNotFound, ErrNotFound, path)
+		return
+	}
+	writeNode(w, n, path)
+}
+
+func (server *Server) post(w http.ResponseWriter, path string, params url.Values) {
+	mime := params.Get("mime")
+	if !mimes[mime] {
+		writeError(w, http.StatusBadRequest, ErrInvalidValue, fmt.Sprintf("unknown mime '%s'", mime))
+		return
+	}
+
+	n := server.find(path)
+	if n == nil {
+		parent := server.find(parentPath(path))
+		if parent == nil || len(splitPath(path)) == 0 {
+			writeError(w, http.StatusNotFound, ErrParentNotFound, path)
+			return
+		}
+		if parent.readonly {
+			writeError(w, http.StatusForbidden, ErrAccessDenied, path)
+			return
+		}
+		name := splitPath(path)[len(splitPath(path))-1]
+		n = newNode(name, mime, params.Get("data"), params.Get("comment"))
+		n.setMeta(params)
+		parent.children[name] = n
+		writeNode(w, n, path)
+		return
+	}
+
+	if n.readonly {
+		writeError(w, http.StatusForbidden, ErrAccessDenied, path)
+		return
+	}
+	if params.Get("version") == "" {
+		writeError(w, http.StatusBadRequest, ErrAlreadyExists, path)
+		return
+	}
+	if params.Get("version") != strconv.Itoa(n.version()) {
+		writeError(w, http.StatusBadRequest, ErrVersionNotMatch, path)
+		return
+	}
+	n.addVersion(mime, params.Get("data"), params.Get("comment"))
+	n.setMeta(params)
+	writeNode(w, n, path)
+}
+
+func (server *Server) delete(w http.ResponseWriter, path string, params url.Values) {
+	n := server.find(path)
+	if n == nil || n == server.root {
+		writeError(w, http.StatusNotFound, ErrNotFound, path)
+		return
+	}
+	if n.readonly {
+		writeError(w, http.StatusForbidden, ErrAccessDenied, path)
+		return
+	}
+	if params.Get("version") != strconv.Itoa(n.version()) {
+		writeError(w, http.StatusBadRequest, ErrVersionNotMatch, path)
+		return
+	}
+	delete(server.find(parentPath(path)).children, n.name)
+	writeJSON(w, http.StatusOK, map[string]interface{}{})
+}
+
+func (server *Server) find(path string) *node {
+	n := server.root
+	for _, name := range splitPath(path) {
+		child, ok := n.children[name]
+		if !ok {
+			return nil
+		}
+		n = child
+	}
+	return n
+}
+
+func newNode(name string, mime string, data string, comment string) *node {
+	n := &node{
+		name:         name,
+		notification: "none",
+		children:     map[string]*node{},
+	}
+	n.addVersion(mime, data, comment)
+	return n
+}
+
+func (n *node) version() int {
+	return len(n.versions)
+}
+
+func (n *node) current() Version {
+	return n.versions[len(n.versions)-1]
+}
+
+func (n *node) addVersion(mime string, data string, comment string) {
+	n.versions = append(n.versions, Version{
+		Version: len(n.versions) + 1,
+		Data:    data,
+		Mime:    mime,
+		Comment: comment,
+	})
+}
+
+func (n *node) setMeta(params url.Values) {
+	n.summary = params.Get("summary")
+	n.description = params.Get("description")
+	if notification := params.Get("notification"); notification != "" {
+		n.notification = notification
+		n.notificationModified = true
+	}
+}
+
+func (n *node) json(path string, withChildren bool) map[string]interface{} {
+	current := n.current()
+	var data interface{} = current.Data
+	if current.Mime == "application/x-null" {
+		data = nil
+	}
+	result := map[string]interface{}{
+		"name":                  n.name,
+		"path":                  "/" + strings.Join(splitPath(path), "/"),
+		"data":                  data,
+		"mime":                  current.Mime,
+		"summary":               n.summary,
+		"description":           n.description,
+		"version":               current.Version,
+		"mtime":                 time.Now().Format("2006-01-02 15:04:05"),
+		"num_children":          len(n.children),
+		"access_modified":       false,
+		"rw":                    !n.readonly,
+		"notification":          n.notification,
+		"notification_modified": n.notificationModified,
+	}
+	if withChildren {
+		names := []string{}
+		for name := range n.children {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		children := []interface{}{}
+		for _, name := range names {
+			children = append(children, n.children[name].json(path+"/"+name, false))
+		}
+		result["children"] = children
+	}
+	return result
+}
+
+func writeNode(w http.ResponseWriter, n *node, path string) {
+	writeJSON(w, http.StatusOK, n.json(path, true))
+}
+
+func writeError(w http.ResponseWriter, statusCode int, kind string, message string) {
+	writeJSON(w, statusCode, map[string]string{
+		"error":   kind,
+		"message": message,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
+
+func splitPath(path string) []string {
+	names := []string{}
+	for _, name := range strings.Split(path, "/") {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func parentPath(path string) string {
+	names := splitPath(path)
+	if len(names) == 0 {
+		return ""
+	}
+	return strings.Join(names[:len(names)-1], "/")
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"net/http"
+	"time"
+)
+
+// default client settings
+const (
+	DefaultTimeout      = 30 * time.Second
+	DefaultRetries      = 3
+	DefaultRetryBackoff = 500 * time.Millisecond
+)
+
+// Option onlineconf client option
+type Option func(client *OnlineConfClient)
+
+// WithHTTPClient use the http client for all requests
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(client *OnlineConfClient) {
+		client.httpClient = httpClient
+	}
+}
+
+// WithTimeout set timeout of the single http request, 0 - no timeout
+func WithTimeout(timeout time.Duration) Option {
+	return func(client *OnlineConfClient) {
+		client.httpClient.Timeout = timeout
+	}
+}
+
+// WithRetries retry GET and DELETE requests on connection errors and 5xx responses,
+// backoff is doubled after every attempt
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(client *OnlineConfClient) {
+		client.retries = retries
+		client.retryBackoff = backoff
+	}
+}
+
+// WithLogLevel log messages up to the level
+func WithLogLevel(level LogLevel) Option {
+	return func(client *OnlineConfClient) {
+		client.logLevel = level
+	}
+}
+
+// WithRedactor mask values of secret nodes in the log
+func WithRedactor(redactor *Redactor) Option {
+	return func(client *OnlineConfClient) {
+		client.redactor = redactor
+	}
+}
+
+func newHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	return &http.Client{
+		Transport: transport,
+		Timeout:   DefaultTimeout,
+	}
+}
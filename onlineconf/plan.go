@@ -0,0 +1,380 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"onlineconf-yaml/state"
+	"onlineconf-yaml/yml/parser"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// NullMime mime of empty onlineconf node
+const NullMime = "application/x-null"
+
+// PlanAction plan action
+type PlanAction string
+
+// plan actions
+const (
+	PlanCreate    PlanAction = "create"
+	PlanUpdate    PlanAction = "update"
+	PlanDelete    PlanAction = "delete"
+	PlanUnchanged PlanAction = "unchanged"
+	PlanProtected PlanAction = "protected"
+)
+
+// PlanItem planned change of the single node
+type PlanItem struct {
+	Key      string     `json:"key"`
+	Action   PlanAction `json:"action"`
+	Type     string     `json:"type,omitempty"`
+	Value    string     `json:"value,omitempty"`
+	OldType  string     `json:"old_type,omitempty"`
+	OldValue string     `json:"old_value,omitempty"`
+	Version  int        `json:"version,omitempty"`
+	// Meta metadata to set, OldMeta live metadata, nil if empty
+	Meta    *parser.NodeMeta `json:"meta,omitempty"`
+	OldMeta *parser.NodeMeta `json:"old_meta,omitempty"`
+	// Drifted live node differs from the value last applied by the import
+	Drifted bool `json:"drifted,omitempty"`
+}
+
+// planNodeActions results of the applied plan actions
+var planNodeActions = map[PlanAction]NodeAction{
+	PlanCreate: NodeCreated,
+	PlanUpdate: NodeUpdated,
+	PlanDelete: NodeDeleted,
+}
+
+// Plan changes required to bring the OnlineConf subtree to the parsed config
+type Plan struct {
+	Root  string     `json:"root"`
+	Items []PlanItem `json:"items"`
+}
+
+// NewPlan compare parsed config with the live subtree, live can be nil if the subtree does not exist
+func NewPlan(root string, config map[string]parser.OnlineConfItem, live *Node) *Plan {
+	nodes := map[string]Node{}
+	if live != nil {
+		nodes = live.Flatten()
+	}
+
+	desired := map[string]parser.OnlineConfItem{}
+	for _, key := range parser.GetParentNodeKeys(config) {
+		desired[key] = parser.OnlineConfItem{Key: key, Type: NullMime}
+	}
+	for k, v := range config {
+		desired[k] = v
+	}
+
+	plan := &Plan{Root: root}
+	for key, item := range desired {
+		node, ok := nodes[key]
+		switch {
+		case !ok:
+			plan.Items = append(plan.Items, PlanItem{
+				Key:    key,
+				Action: PlanCreate,
+				Type:   item.Type,
+				Value:  item.Value,
+				Meta:   metaRef(item.Meta),
+			})
+		case (item.Type == NullMime || (node.Mime == item.Type && node.Data == item.Value)) && node.metaMatches(item.Meta):
+			// data of parent nodes is kept as is
+			plan.Items = append(plan.Items, PlanItem{
+				Key:     key,
+				Action:  PlanUnchanged,
+				Type:    node.Mime,
+				Value:   node.Data,
+				Version: node.Version,
+			})
+		default:
+			if item.Type == NullMime {
+				item.Type, item.Value = node.Mime, node.Data
+			}
+			plan.Items = append(plan.Items, PlanItem{
+				Key:      key,
+				Action:   PlanUpdate,
+				Type:     item.Type,
+				Value:    item.Value,
+				OldType:  node.Mime,
+				OldValue: node.Data,
+				Version:  node.Version,
+				Meta:     metaRef(node.mergeMeta(item.Meta)),
+				OldMeta:  metaRef(node.Meta()),
+			})
+		}
+	}
+
+	for key, node := range nodes {
+		if _, ok := desired[key]; ok {
+			continue
+		}
+		plan.Items = append(plan.Items, PlanItem{
+			Key:      key,
+			Action:   PlanDelete,
+			OldType:  node.Mime,
+			OldValue: node.Data,
+			Version:  node.Version,
+			OldMeta:  metaRef(node.Meta()),
+		})
+	}
+
+	plan.sort()
+	return plan
+}
+
+// MarkDrifted mark updates and deletes of nodes changed in OnlineConf since the last import
+func (plan *Plan) MarkDrifted(lastApplied *state.State) {
+	for i, item := range plan.Items {
+		if item.Action != PlanUpdate && item.Action != PlanDelete {
+			continue
+		}
+		entry, ok := lastApplied.Get(item.Key)
+		plan.Items[i].Drifted = ok && !entry.Matches(item.OldType, item.OldValue)
+	}
+}
+
+// sort creates and updates parent first, then deletes deepest first
+func (plan *Plan) sort() {
+	order := map[PlanAction]int{PlanCreate: 0, PlanUpdate: 0, PlanUnchanged: 0, PlanDelete: 1, PlanProtected: 1}
+	sort.SliceStable(plan.Items, func(i, j int) bool {
+		a, b := plan.Items[i], plan.Items[j]
+		if order[a.Action] != order[b.Action] {
+			return order[a.Action] < order[b.Action]
+		}
+		if order[a.Action] == 1 {
+			return a.Key > b.Key
+		}
+		return a.Key < b.Key
+	})
+}
+
+// Count number of items with the action
+func (plan *Plan) Count(action PlanAction) int {
+	count := 0
+	for _, item := range plan.Items {
+		if item.Action == action {
+			count++
+		}
+	}
+	return count
+}
+
+// HasChanges plan has something to apply
+func (plan *Plan) HasChanges() bool {
+	return plan.Count(PlanCreate)+plan.Count(PlanUpdate)+plan.Count(PlanDelete) > 0
+}
+
+// Print show plan
+func (plan *Plan) Print(w io.Writer, showUnchanged bool) {
+	for _, item := range plan.Items {
+		switch item.Action {
+		case PlanCreate:
+			fmt.Fprintf(w, "+ %-50s (%-30s) : %v\n", item.Key, item.Type, item.Value)
+			printMeta(w, nil, item.Meta)
+		case PlanUpdate:
+			fmt.Fprintf(w, "~ %-50s (%-30s) : %v%s\n", item.Key, item.OldType, item.OldValue, item.driftNote())
+			fmt.Fprintf(w, "  %-50s (%-30s) : %v\n", "", item.Type, item.Value)
+			printMeta(w, item.OldMeta, item.Meta)
+		case PlanDelete:
+			fmt.Fprintf(w, "- %-50s (%-30s) : %v%s\n", item.Key, item.OldType, item.OldValue, item.driftNote())
+		case PlanProtected:
+			fmt.Fprintf(w, "! %-50s (%-30s) : protected from deletion\n", item.Key, item.OldType)
+		case PlanUnchanged:
+			if showUnchanged {
+				fmt.Fprintf(w, "  %-50s (%-30s) : %v\n", item.Key, item.Type, item.Value)
+			}
+		}
+	}
+	fmt.Fprintf(w, "Plan: %d to create, %d to update, %d to delete, %d unchanged\n",
+		plan.Count(PlanCreate), plan.Count(PlanUpdate), plan.Count(PlanDelete), plan.Count(PlanUnchanged))
+}
+
+// driftNote note of the node changed in OnlineConf since the last import
+func (item PlanItem) driftNote() string {
+	if item.Drifted {
+		return " (changed in OnlineConf since the last import)"
+	}
+	return ""
+}
+
+// printMeta show changed metadata fields
+func printMeta(w io.Writer, oldMeta *parser.NodeMeta, meta *parser.NodeMeta) {
+	old, current := metaValue(oldMeta), metaValue(meta)
+	fields := []struct{ name, old, current string }{
+		{"summary", old.Summary, current.Summary},
+		{"description", old.Description, current.Description},
+		{"notification", old.Notification, current.Notification},
+	}
+	for _, field := range fields {
+		if field.old != field.current {
+			fmt.Fprintf(w, "  %-50s  %s: %q -> %q\n", "", field.name, field.old, field.current)
+		}
+	}
+}
+
+// Save write plan to the file
+func (plan *Plan) Save(filepath string) error {
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath, data, 0644)
+}
+
+// LoadPlan read plan from the file
+func LoadPlan(filepath string) (*Plan, error) {
+	data, err := os.ReadFile(filepath)
+	if err != nil {
+		return nil, err
+	}
+	var plan Plan
+	err = json.Unmarshal(data, &plan)
+	if err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// GetPlan fetch the live subtree and compare it with the parsed config
+func (client *OnlineConfClient) GetPlan(ctx context.Context, root string, config map[string]parser.OnlineConfItem) (*Plan, error) {
+	live, err := client.GetTree(ctx, "", -1)
+	if err != nil {
+		return nil, err
+	}
+	return NewPlan(root, config, live), nil
+}
+
+// ApplyPlan execute plan, updates and deletes fail if the node version changed since planning,
+// drifted updates and deletes are resolved by the conflict policy
+func (client *OnlineConfClient) ApplyPlan(ctx context.Context, plan *Plan, options ImportOptions) error {
+	for _, item := range plan.Items {
+		if item.Action != PlanCreate && item.Action != PlanUpdate && item.Action != PlanDelete {
+			continue
+		}
+		operation := string(item.Action) + " " + item.Key
+		action := planNodeActions[item.Action]
+		if entry, ok := options.done(operation); ok {
+			if entry.Result == string(NodeKept) {
+				action = NodeKept
+			}
+			options.resumed(item.Key, action, entry.Version)
+			continue
+		}
+		if options.stopped() {
+			return ErrInterrupted
+		}
+
+		start := time.Now()
+		if item.Drifted {
+			overwrite, err := resolveConflict(Conflict{
+				Key:       item.Key,
+				LiveType:  item.OldType,
+				LiveValue: item.OldValue,
+				NewType:   item.Type,
+				NewValue:  item.Value,
+			}, options)
+			if err != nil {
+				options.report(item.Key, "", item.Version, 0, start, err)
+				return err
+			}
+			if !overwrite {
+				client.logf(LogInfo, "kept key: %+v\n", item.Key)
+				options.report(item.Key, NodeKept, item.Version, item.Version, start, nil)
+				err = options.record(operation, string(NodeKept), item.Version)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		client.logf(LogInfo, "%s key: %+v\n", item.Action, item.Key)
+		version := 0
+		var err error
+		if item.Action == PlanDelete {
+			err = client.deleteNode(ctx, item.Key, item.Version, options.Comment)
+			if err == nil {
+				options.changed(Change{Key: item.Key, Action: PlanDelete, Mime: item.OldType, Data: item.OldValue, Meta: metaValue(item.OldMeta)})
+				if options.LastApplied != nil {
+					options.LastApplied.Delete(item.Key)
+				}
+			}
+		} else {
+			version, err = client.setNode(ctx, item.Key, item.Type, item.Value, metaValue(item.Meta), item.Version, options.Comment)
+			if err == nil {
+				options.changed(Change{Key: item.Key, Action: item.Action, Mime: item.OldType, Data: item.OldValue, Meta: metaValue(item.OldMeta), Version: version})
+				if options.LastApplied != nil {
+					options.LastApplied.Set(item.Key, item.Type, item.Value, version)
+				}
+			}
+		}
+		options.report(item.Key, action, item.Version, version, start, err)
+		if err != nil {
+			return err
+		}
+
+		err = options.record(operation, string(item.Action), version)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setNode create node if version is 0, otherwise update node of the version, returns the new version,
+// empty summary and description are cleared, empty notification is kept
+func (client *OnlineConfClient) setNode(ctx context.Context, key string, mime string, data string, meta parser.NodeMeta, version int, comment string) (int, error) {
+	params := map[string]string{
+		"summary":      meta.Summary,
+		"description":  meta.Description,
+		"notification": meta.Notification,
+		"mime":         mime,
+		"data":         data,
+		"comment":      comment,
+	}
+	if version != 0 {
+		params["version"] = strconv.Itoa(version)
+	}
+
+	statusCode, result, err := client.request(ctx, key, http.MethodPost, params)
+	client.logf(LogInfo, "POST key %s, status: %+v, err: %+v\n", key, statusCode, err)
+	if err != nil {
+		return 0, err
+	}
+	if statusCode != http.StatusOK {
+		return 0, fmt.Errorf("set node failure...%w", newResponseError(statusCode, result))
+	}
+	return responseVersion(result), nil
+}
+
+// responseVersion version of the node in the response, 0 if the response has no node
+func responseVersion(result string) int {
+	var response OnlineConfResponse
+	json.Unmarshal([]byte(result), &response)
+	return response.Version
+}
+
+func (client *OnlineConfClient) deleteNode(ctx context.Context, key string, version int, comment string) error {
+	params := map[string]string{
+		"version": strconv.Itoa(version),
+		"comment": comment,
+	}
+
+	statusCode, result, err := client.request(ctx, key, http.MethodDelete, params)
+	client.logf(LogInfo, "DELETE key %s, status: %+v, err: %+v\n", key, statusCode, err)
+	if err != nil {
+		return err
+	}
+	if statusCode != http.StatusOK {
+		return fmt.Errorf("delete node failure...%w", newResponseError(statusCode, result))
+	}
+	return nil
+}
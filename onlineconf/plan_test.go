@@ -0,0 +1,190 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"onlineconf-yaml/onlineconf/onlineconftest"
+	"onlineconf-yaml/state"
+	"onlineconf-yaml/yml/parser"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPlan(t *testing.T) {
+
+	config := map[string]parser.OnlineConfItem{
+		"fee/common/KEY1": {Key: "fee/common/KEY1", Value: "4", Type: "text/plain"},
+		"fee/common/KEY2": {Key: "fee/common/KEY2", Value: "5", Type: "text/plain"},
+		"fee/new":         {Key: "fee/new", Value: "- 1\n- 2", Type: "application/x-yaml"},
+	}
+
+	live := &Node{
+		Children: []Node{
+			{Name: "fee", Mime: NullMime, Version: 1, NumChildren: 2, Children: []Node{
+				{Name: "common", Mime: NullMime, Version: 1, NumChildren: 3, Children: []Node{
+					{Name: "KEY1", Data: "4", Mime: "text/plain", Version: 2},
+					{Name: "KEY2", Data: "3", Mime: "text/plain", Version: 7},
+					{Name: "KEY3", Data: "1", Mime: "text/plain", Version: 1},
+				}},
+				{Name: "old", Mime: NullMime, Version: 3, NumChildren: 1, Children: []Node{
+					{Name: "KEY", Data: "1", Mime: "text/plain", Version: 4},
+				}},
+			}},
+		},
+	}
+
+	plan := NewPlan("importConfig", config, live)
+
+	expected := []PlanItem{
+		{Key: "fee", Action: PlanUnchanged, Type: NullMime, Version: 1},
+		{Key: "fee/common", Action: PlanUnchanged, Type: NullMime, Version: 1},
+		{Key: "fee/common/KEY1", Action: PlanUnchanged, Type: "text/plain", Value: "4", Version: 2},
+		{Key: "fee/common/KEY2", Action: PlanUpdate, Type: "text/plain", Value: "5", OldType: "text/plain", OldValue: "3", Version: 7},
+		{Key: "fee/new", Action: PlanCreate, Type: "application/x-yaml", Value: "- 1\n- 2"},
+		{Key: "fee/old/KEY", Action: PlanDelete, OldType: "text/plain", OldValue: "1", Version: 4},
+		{Key: "fee/old", Action: PlanDelete, OldType: NullMime, Version: 3},
+		{Key: "fee/common/KEY3", Action: PlanDelete, OldType: "text/plain", OldValue: "1", Version: 1},
+	}
+
+	assert.Equal(t, "importConfig", plan.Root)
+	assert.Equal(t, expected, plan.Items)
+	assert.Equal(t, 1, plan.Count(PlanCreate))
+	assert.Equal(t, 3, plan.Count(PlanDelete))
+	assert.True(t, plan.HasChanges())
+
+	plan = NewPlan("importConfig", config, nil)
+	assert.Equal(t, 5, plan.Count(PlanCreate))
+	assert.Equal(t, "fee", plan.Items[0].Key)
+}
+
+func TestPlanProtect(t *testing.T) {
+
+	plan := &Plan{Items: []PlanItem{
+		{Key: "fee/old/KEY", Action: PlanDelete},
+		{Key: "fee/old", Action: PlanDelete},
+		{Key: "fee/manual/KEY", Action: PlanDelete},
+		{Key: "fee/manual", Action: PlanDelete},
+		{Key: "fee/common/KEY3", Action: PlanDelete},
+	}}
+
+	err := plan.Protect([]string{"fee/manual", "fee/*/KEY3"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, PlanDelete, plan.Items[0].Action)
+	assert.Equal(t, PlanDelete, plan.Items[1].Action)
+	assert.Equal(t, PlanProtected, plan.Items[2].Action)
+	assert.Equal(t, PlanProtected, plan.Items[3].Action)
+	assert.Equal(t, PlanProtected, plan.Items[4].Action)
+
+	assert.NoError(t, plan.CheckDeletions(0))
+	assert.NoError(t, plan.CheckDeletions(2))
+	assert.Error(t, plan.CheckDeletions(1))
+
+	assert.Error(t, plan.Protect([]string{"fee/["}))
+}
+
+func TestApplyPlan(t *testing.T) {
+
+	server := onlineconftest.NewServer()
+	defer server.Close()
+	server.SetNode("importConfig/fee/common/KEY1", "text/plain", "4")
+	server.SetNode("importConfig/fee/common/KEY2", "text/plain", "3")
+	server.SetNode("importConfig/fee/old/KEY", "text/plain", "1")
+
+	client, err := NewOnlineConfClient(server.NodeURL("importConfig"), "", "")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	config := map[string]parser.OnlineConfItem{
+		"fee/common/KEY1": {Key: "fee/common/KEY1", Value: "4", Type: "text/plain"},
+		"fee/common/KEY2": {Key: "fee/common/KEY2", Value: "5", Type: "text/plain"},
+		"fee/new/KEY":     {Key: "fee/new/KEY", Value: "- 1\n- 2", Type: "application/x-yaml"},
+	}
+
+	plan, err := client.GetPlan(ctx, "importConfig", config)
+	require.NoError(t, err)
+	assert.Equal(t, 2, plan.Count(PlanCreate))
+	assert.Equal(t, 1, plan.Count(PlanUpdate))
+	assert.Equal(t, 2, plan.Count(PlanDelete))
+
+	err = client.ApplyPlan(ctx, plan, ImportOptions{Comment: "sync"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"/importConfig",
+		"/importConfig/fee",
+		"/importConfig/fee/common",
+		"/importConfig/fee/common/KEY1",
+		"/importConfig/fee/common/KEY2",
+		"/importConfig/fee/new",
+		"/importConfig/fee/new/KEY",
+	}, server.Paths())
+
+	plan, err = client.GetPlan(ctx, "importConfig", config)
+	require.NoError(t, err)
+	assert.False(t, plan.HasChanges())
+
+	// node changed after planning
+	config["fee/common/KEY1"] = parser.OnlineConfItem{Key: "fee/common/KEY1", Value: "6", Type: "text/plain"}
+	plan, err = client.GetPlan(ctx, "importConfig", config)
+	require.NoError(t, err)
+	server.SetNode("importConfig/fee/common/KEY1", "text/plain", "7")
+	assert.ErrorIs(t, client.ApplyPlan(ctx, plan, ImportOptions{Comment: "sync"}), ErrVersionConflict)
+}
+
+func TestPruneManagedOnly(t *testing.T) {
+
+	server := onlineconftest.NewServer()
+	defer server.Close()
+	server.SetNode("importConfig", "application/x-null", "")
+
+	client, err := NewOnlineConfClient(server.NodeURL("importConfig"), "", "")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	config := map[string]parser.OnlineConfItem{
+		"fee/KEY1": {Key: "fee/KEY1", Value: "4", Type: "text/plain"},
+		"fee/KEY2": {Key: "fee/KEY2", Value: "4", Type: "text/plain"},
+	}
+	options := ImportOptions{UpdateIfExists: true, LastApplied: state.New("importConfig"), TrustLastApplied: true}
+	_, err = client.Import(ctx, config, options)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fee", "fee/KEY1", "fee/KEY2"}, options.LastApplied.Keys())
+
+	// no requests for nodes known to be unchanged
+	gets := server.Requests("GET")
+	stats, err := client.Import(ctx, config, options)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats[NodeUnchanged])
+	assert.Equal(t, gets, server.Requests("GET"))
+
+	server.SetNode("importConfig/fee/manual", "text/plain", "1")
+	delete(config, "fee/KEY2")
+
+	plan, err := client.PruneNodes(ctx, "importConfig", config, PruneOptions{ManagedOnly: true}, options)
+	require.NoError(t, err)
+	assert.Equal(t, 1, plan.Count(PlanDelete))
+	assert.Equal(t, 1, plan.Count(PlanProtected))
+	assert.Equal(t, []string{"/importConfig", "/importConfig/fee", "/importConfig/fee/KEY1", "/importConfig/fee/manual"}, server.Paths())
+	assert.Equal(t, []string{"fee", "fee/KEY1"}, options.LastApplied.Keys())
+}
+
+func TestPlanPrintDrifted(t *testing.T) {
+
+	plan := &Plan{Root: "importConfig", Items: []PlanItem{
+		{Key: "fee/KEY1", Action: PlanUpdate, Type: "text/plain", Value: "6", OldType: "text/plain", OldValue: "5", Drifted: true},
+		{Key: "fee/KEY2", Action: PlanUpdate, Type: "text/plain", Value: "6", OldType: "text/plain", OldValue: "4"},
+		{Key: "fee/OLD", Action: PlanDelete, OldType: "text/plain", OldValue: "2", Drifted: true},
+	}}
+	var output bytes.Buffer
+	plan.Print(&output, false)
+	lines := strings.Split(output.String(), "\n")
+	assert.Contains(t, lines[0], "fee/KEY1")
+	assert.Contains(t, lines[0], "(changed in OnlineConf since the last import)")
+	assert.NotContains(t, lines[2], "changed in OnlineConf")
+	assert.Contains(t, lines[4], "fee/OLD")
+	assert.Contains(t, lines[4], "(changed in OnlineConf since the last import)")
+}
@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"onlineconf-yaml/state"
+	"onlineconf-yaml/yml/parser"
+	"path"
+	"strings"
+)
+
+// PruneOptions deletion safety options
+type PruneOptions struct {
+	// ProtectedPaths path.Match patterns of keys which are never deleted with their descendants and ancestors
+	ProtectedPaths []string
+	// MaxDeletions maximum number of deletions, 0 - no limit
+	MaxDeletions int
+	// ManagedOnly delete only nodes recorded in ImportOptions.LastApplied
+	ManagedOnly bool
+}
+
+// Protect exclude protected nodes from deletion
+func (plan *Plan) Protect(patterns []string) error {
+	for _, item := range plan.Items {
+		if item.Action != PlanDelete {
+			continue
+		}
+		if _, err := isProtected(item.Key, patterns); err != nil {
+			return err
+		}
+	}
+	plan.protect(func(key string) bool {
+		ok, _ := isProtected(key, patterns)
+		return ok
+	})
+	return nil
+}
+
+// Scope exclude nodes not created by the import from deletion
+func (plan *Plan) Scope(lastApplied *state.State) {
+	plan.protect(func(key string) bool {
+		_, ok := lastApplied.Get(key)
+		return !ok
+	})
+}
+
+func (plan *Plan) protect(isProtected func(key string) bool) {
+	protected := map[string]bool{}
+	for _, item := range plan.Items {
+		if item.Action != PlanDelete || !isProtected(item.Key) {
+			continue
+		}
+		// deleting an ancestor removes the protected node too
+		key := item.Key
+		for key != "" {
+			protected[key] = true
+			key = parentKey(key)
+		}
+	}
+
+	for i, item := range plan.Items {
+		if item.Action == PlanDelete && protected[item.Key] {
+			plan.Items[i].Action = PlanProtected
+		}
+	}
+}
+
+// CheckDeletions fail if plan deletes more nodes than allowed, 0 - no limit
+func (plan *Plan) CheckDeletions(maxDeletions int) error {
+	deletions := plan.Count(PlanDelete)
+	if maxDeletions > 0 && deletions > maxDeletions {
+		return fmt.Errorf("plan deletes %d nodes, maximum is %d", deletions, maxDeletions)
+	}
+	return nil
+}
+
+// Deletions plan with deletes only
+func (plan *Plan) Deletions() *Plan {
+	deletions := &Plan{Root: plan.Root}
+	for _, item := range plan.Items {
+		if item.Action == PlanDelete || item.Action == PlanProtected {
+			deletions.Items = append(deletions.Items, item)
+		}
+	}
+	return deletions
+}
+
+// PruneNodes delete nodes which are not present in the parsed config, deepest first
+func (client *OnlineConfClient) PruneNodes(ctx context.Context, root string, config map[string]parser.OnlineConfItem, pruneOptions PruneOptions, options ImportOptions) (*Plan, error) {
+	plan, err := client.GetPlan(ctx, root, config)
+	if err != nil {
+		return nil, err
+	}
+	plan = plan.Deletions()
+
+	err = plan.Protect(pruneOptions.ProtectedPaths)
+	if err != nil {
+		return plan, err
+	}
+	if pruneOptions.ManagedOnly {
+		if options.LastApplied == nil {
+			return plan, fmt.Errorf("state is required to prune managed nodes only")
+		}
+		plan.Scope(options.LastApplied)
+	}
+	err = plan.CheckDeletions(pruneOptions.MaxDeletions)
+	if err != nil {
+		return plan, err
+	}
+
+	client.logf(LogInfo, "prune %d nodes\n", plan.Count(PlanDelete))
+	return plan, client.ApplyPlan(ctx, plan, options)
+}
+
+func isProtected(key string, patterns []string) (bool, error) {
+	for key != "" {
+		for _, pattern := range patterns {
+			ok, err := path.Match(strings.Trim(pattern, "/"), key)
+			if err != nil {
+				return false, fmt.Errorf("bad protected path '%s'... %s", pattern, err.Error())
+			}
+			if ok {
+				return true, nil
+			}
+		}
+		key = parentKey(key)
+	}
+	return false, nil
+}
+
+func parentKey(key string) string {
+	i := strings.LastIndex(key, "/")
+	if i < 0 {
+		return ""
+	}
+	return key[:i]
+}
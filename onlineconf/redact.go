@@ -0,0 +1,165 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RedactedValue replaces values of secret nodes in the log and output
+const RedactedValue = "***"
+
+// DefaultSecretPatterns patterns of secret node names
+var DefaultSecretPatterns = []string{"*password*", "*passwd*", "*secret*", "*token*", "*api_key*", "*apikey*", "*private_key*", "*credential*"}
+
+// Redactor masks values of secret nodes, safe for concurrent use, nil redactor masks nothing
+type Redactor struct {
+	mu       sync.Mutex
+	patterns []string
+	keys     map[string]bool
+}
+
+// NewRedactor node is secret if any name in its key matches any pattern, case insensitive
+func NewRedactor(patterns []string) (*Redactor, error) {
+	redactor := &Redactor{keys: map[string]bool{}}
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("bad secret pattern '%s'... %s", pattern, err.Error())
+		}
+		redactor.patterns = append(redactor.patterns, pattern)
+	}
+	return redactor, nil
+}
+
+// AddKeys mark nodes and their descendants as secret, e.g. nodes tagged as secret in yaml
+func (redactor *Redactor) AddKeys(keys []string) {
+	redactor.mu.Lock()
+	defer redactor.mu.Unlock()
+	for _, key := range keys {
+		redactor.keys[strings.Trim(key, "/")] = true
+	}
+}
+
+// IsSecret node or its ancestor is secret
+func (redactor *Redactor) IsSecret(key string) bool {
+	if redactor == nil {
+		return false
+	}
+	redactor.mu.Lock()
+	defer redactor.mu.Unlock()
+
+	key = strings.Trim(key, "/")
+	for ancestor := key; ; ancestor = parentKey(ancestor) {
+		if redactor.keys[ancestor] {
+			return true
+		}
+		if ancestor == "" {
+			break
+		}
+	}
+	for _, name := range strings.Split(strings.ToLower(key), "/") {
+		for _, pattern := range redactor.patterns {
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Value masked value of the secret node, value as is otherwise
+func (redactor *Redactor) Value(key string, value string) string {
+	if value != "" && redactor.IsSecret(key) {
+		return RedactedValue
+	}
+	return value
+}
+
+// Params request params with masked data of the secret node
+func (redactor *Redactor) Params(key string, params map[string]string) string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := make([]string, 0, len(names))
+	for _, name := range names {
+		value := params[name]
+		if name == "data" {
+			value = redactor.Value(key, value)
+		}
+		list = append(list, fmt.Sprintf("%s=%q", name, value))
+	}
+	return strings.Join(list, " ")
+}
+
+// Body response body with masked data of the secret node and its secret children,
+// body which is not a node is returned as is
+func (redactor *Redactor) Body(key string, body string) string {
+	if redactor == nil {
+		return body
+	}
+	var node map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &node); err != nil {
+		return body
+	}
+	if !redactor.redactNode(key, node) {
+		return body
+	}
+	data, err := json.Marshal(node)
+	if err != nil {
+		return body
+	}
+	return string(data)
+}
+
+func (redactor *Redactor) redactNode(key string, node map[string]interface{}) bool {
+	redacted := false
+	if data, ok := node["data"].(string); ok && data != "" && redactor.IsSecret(key) {
+		node["data"] = RedactedValue
+		redacted = true
+	}
+	children, _ := node["children"].([]interface{})
+	for _, child := range children {
+		childNode, ok := child.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := childNode["name"].(string)
+		if redactor.redactNode(joinKey(key, name), childNode) {
+			redacted = true
+		}
+	}
+	return redacted
+}
+
+// Redacted copy of the plan with masked values of secret nodes to show
+func (plan *Plan) Redacted(redactor *Redactor) *Plan {
+	redacted := &Plan{Root: plan.Root, Items: make([]PlanItem, len(plan.Items))}
+	for i, item := range plan.Items {
+		item.Value = redactor.Value(item.Key, item.Value)
+		item.OldValue = redactor.Value(item.Key, item.OldValue)
+		redacted.Items[i] = item
+	}
+	return redacted
+}
+
+// Redacted copy of the report with masked values of secret nodes
+func (report *DriftReport) Redacted(redactor *Redactor) *DriftReport {
+	redacted := *report
+	redacted.Items = make([]DriftItem, len(report.Items))
+	for i, item := range report.Items {
+		item.Value = redactor.Value(item.Key, item.Value)
+		item.LiveValue = redactor.Value(item.Key, item.LiveValue)
+		redacted.Items[i] = item
+	}
+	return &redacted
+}
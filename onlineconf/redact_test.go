@@ -0,0 +1,102 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"testing"
+
+	"onlineconf-yaml/onlineconf/onlineconftest"
+	"onlineconf-yaml/yml/parser"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactor(t *testing.T) {
+
+	redactor, err := NewRedactor([]string{"*PASSWORD*", " *token "})
+	require.NoError(t, err)
+	redactor.AddKeys([]string{"fee/private"})
+
+	assert.True(t, redactor.IsSecret("db/password"))
+	assert.True(t, redactor.IsSecret("db/DB_PASSWORD"))
+	assert.True(t, redactor.IsSecret("passwords/db"))
+	assert.True(t, redactor.IsSecret("github/token"))
+	assert.True(t, redactor.IsSecret("fee/private"))
+	assert.True(t, redactor.IsSecret("fee/private/KEY"))
+	assert.False(t, redactor.IsSecret("fee/public"))
+	assert.False(t, redactor.IsSecret("github/tokens_count"))
+
+	assert.Equal(t, RedactedValue, redactor.Value("db/password", "qwerty"))
+	assert.Equal(t, "", redactor.Value("db/password", ""))
+	assert.Equal(t, "localhost", redactor.Value("db/host", "localhost"))
+	assert.Equal(t, `comment="init" data="***" mime="text/plain"`,
+		redactor.Params("db/password", map[string]string{"data": "qwerty", "mime": "text/plain", "comment": "init"}))
+
+	body := `{"name":"db","data":null,"children":[{"name":"host","data":"localhost"},{"name":"password","data":"qwerty"}]}`
+	assert.NotContains(t, redactor.Body("db", body), "qwerty")
+	assert.Contains(t, redactor.Body("db", body), "localhost")
+	assert.Equal(t, `{"error":"NotFound"}`, redactor.Body("db/password", `{"error":"NotFound"}`))
+	assert.Equal(t, "<html>qwerty</html>", redactor.Body("db/password", "<html>qwerty</html>"))
+
+	var nilRedactor *Redactor
+	assert.Equal(t, "qwerty", nilRedactor.Value("db/password", "qwerty"))
+	assert.Equal(t, body, nilRedactor.Body("db", body))
+
+	plan := &Plan{Items: []PlanItem{{Key: "db/password", Action: PlanUpdate, Value: "new", OldValue: "old"}}}
+	assert.Equal(t, RedactedValue, plan.Redacted(redactor).Items[0].Value)
+	assert.Equal(t, RedactedValue, plan.Redacted(redactor).Items[0].OldValue)
+	assert.Equal(t, "new", plan.Items[0].Value)
+
+	_, err = NewRedactor([]string{"["})
+	assert.Error(t, err)
+}
+
+func TestClientLogLevel(t *testing.T) {
+
+	server := onlineconftest.NewServer()
+	defer server.Close()
+	server.SetNode("importConfig/db/password", "text/plain", "old-secret")
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	redactor, err := NewRedactor(DefaultSecretPatterns)
+	require.NoError(t, err)
+	ctx := context.Background()
+	item := parser.OnlineConfItem{Key: "db/password", Value: "new-secret", Type: "text/plain"}
+
+	client, err := NewOnlineConfClient(server.NodeURL("importConfig"), "", "", WithRedactor(redactor), WithLogLevel(LogDebug))
+	require.NoError(t, err)
+	_, err = client.CreateNode(ctx, item, true, false, "")
+	require.NoError(t, err)
+	_, err = client.GetTree(ctx, "", -1)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "response status")
+	assert.Contains(t, buf.String(), RedactedValue)
+	assert.NotContains(t, buf.String(), "old-secret")
+	assert.NotContains(t, buf.String(), "new-secret")
+
+	buf.Reset()
+	client, err = NewOnlineConfClient(server.NodeURL("importConfig"), "", "")
+	require.NoError(t, err)
+	item.Value = "newer-secret"
+	_, err = client.CreateNode(ctx, item, true, false, "")
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "update key: db/password")
+	assert.NotContains(t, buf.String(), "response status")
+	assert.NotContains(t, buf.String(), "secret")
+
+	buf.Reset()
+	client, err = NewOnlineConfClient(server.NodeURL("importConfig"), "", "", WithLogLevel(LogError))
+	require.NoError(t, err)
+	_, err = client.GetNode(ctx, "db/password")
+	require.NoError(t, err)
+	assert.Empty(t, buf.String())
+
+	_, err = ParseLogLevel("verbose")
+	assert.Error(t, err)
+}
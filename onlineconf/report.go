@@ -0,0 +1,151 @@
+package client
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// ReportStatus result of the whole run or the single node
+type ReportStatus string
+
+// report statuses
+const (
+	// ReportNoChanges nothing is changed in OnlineConf
+	ReportNoChanges ReportStatus = "no_changes"
+	// ReportChanged some nodes are created, updated or deleted
+	ReportChanged ReportStatus = "changed"
+	// ReportFailed run or node failed
+	ReportFailed ReportStatus = "failed"
+	// ReportOK node is processed
+	ReportOK ReportStatus = "ok"
+	// ReportResumed node is processed by the interrupted run recorded in the journal
+	ReportResumed ReportStatus = "resumed"
+)
+
+// exit codes of the run, like terraform -detailed-exitcode
+const (
+	ExitNoChanges = 0
+	ExitFailed    = 1
+	ExitChanged   = 2
+)
+
+// ReportItem result of the single node
+type ReportItem struct {
+	Key        string       `json:"key"`
+	Action     NodeAction   `json:"action,omitempty"`
+	OldVersion int          `json:"old_version,omitempty"`
+	NewVersion int          `json:"new_version,omitempty"`
+	Status     ReportStatus `json:"status"`
+	Error      string       `json:"error,omitempty"`
+	// Duration seconds spent on the node
+	Duration float64 `json:"duration"`
+}
+
+// Report results of the run, safe for concurrent use
+type Report struct {
+	mu sync.Mutex
+
+	Root     string         `json:"root"`
+	Started  time.Time      `json:"started"`
+	Finished time.Time      `json:"finished"`
+	Status   ReportStatus   `json:"status"`
+	Error    string         `json:"error,omitempty"`
+	Summary  map[string]int `json:"summary"`
+	Items    []ReportItem   `json:"items"`
+}
+
+// NewReport start report of the root node run
+func NewReport(root string) *Report {
+	return &Report{
+		Root:    root,
+		Started: time.Now().UTC(),
+		Summary: map[string]int{},
+		Items:   []ReportItem{},
+	}
+}
+
+// Add add result of the node
+func (report *Report) Add(item ReportItem) {
+	report.mu.Lock()
+	defer report.mu.Unlock()
+	report.Items = append(report.Items, item)
+	if item.Status == ReportFailed {
+		report.Summary[string(ReportFailed)]++
+		return
+	}
+	report.Summary[string(item.Action)]++
+}
+
+// Finish set status of the run, err is the error of the run, if any
+func (report *Report) Finish(err error) {
+	report.mu.Lock()
+	defer report.mu.Unlock()
+	report.Finished = time.Now().UTC()
+	switch {
+	case err != nil:
+		report.Status = ReportFailed
+		report.Error = err.Error()
+	case report.Summary[string(NodeCreated)]+report.Summary[string(NodeUpdated)]+report.Summary[string(NodeDeleted)] > 0:
+		report.Status = ReportChanged
+	default:
+		report.Status = ReportNoChanges
+	}
+}
+
+// ExitCode exit code of the finished run
+func (report *Report) ExitCode() int {
+	report.mu.Lock()
+	defer report.mu.Unlock()
+	switch report.Status {
+	case ReportFailed:
+		return ExitFailed
+	case ReportChanged:
+		return ExitChanged
+	default:
+		return ExitNoChanges
+	}
+}
+
+// Write write report as JSON
+func (report *Report) Write(w io.Writer) error {
+	report.mu.Lock()
+	defer report.mu.Unlock()
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// report add result of the node to the report, if set
+func (options ImportOptions) report(key string, action NodeAction, oldVersion int, version int, start time.Time, err error) {
+	if options.Report == nil {
+		return
+	}
+	item := ReportItem{
+		Key:        key,
+		Action:     action,
+		OldVersion: oldVersion,
+		NewVersion: version,
+		Status:     ReportOK,
+		Duration:   time.Since(start).Seconds(),
+	}
+	if err != nil {
+		item.Status = ReportFailed
+		item.Error = err.Error()
+	}
+	options.Report.Add(item)
+}
+
+// resumed add node processed by the interrupted run to the report, if set
+func (options ImportOptions) resumed(key string, action NodeAction, version int) {
+	if options.Report == nil {
+		return
+	}
+	options.Report.Add(ReportItem{
+		Key:        key,
+		Action:     action,
+		NewVersion: version,
+		Status:     ReportResumed,
+	})
+}
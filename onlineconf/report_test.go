@@ -0,0 +1,86 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"onlineconf-yaml/onlineconf/onlineconftest"
+	"onlineconf-yaml/yml/parser"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReport(t *testing.T) {
+
+	server := onlineconftest.NewServer()
+	defer server.Close()
+	server.SetNode("importConfig/fee/KEY1", "text/plain", "1")
+	server.SetNode("importConfig/fee/KEY2", "text/plain", "2")
+	server.SetNode("importConfig/fee/LOCKED", "text/plain", "3")
+	server.Deny("importConfig/fee/LOCKED")
+
+	client, err := NewOnlineConfClient(server.NodeURL("importConfig"), "", "")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	config := map[string]parser.OnlineConfItem{
+		"fee/KEY1": {Key: "fee/KEY1", Value: "1", Type: "text/plain"},
+		"fee/KEY2": {Key: "fee/KEY2", Value: "5", Type: "text/plain"},
+		"fee/KEY3": {Key: "fee/KEY3", Value: "3", Type: "text/plain"},
+	}
+	options := ImportOptions{UpdateIfExists: true, SkipAlreadyExist: true, Report: NewReport("importConfig")}
+	_, err = client.Import(ctx, config, options)
+	require.NoError(t, err)
+	options.Report.Finish(nil)
+
+	items := map[string]ReportItem{}
+	for _, item := range options.Report.Items {
+		assert.GreaterOrEqual(t, item.Duration, 0.0)
+		item.Duration = 0
+		items[item.Key] = item
+	}
+	assert.Equal(t, map[string]ReportItem{
+		"fee":      {Key: "fee", Action: NodeSkipped, Status: ReportOK},
+		"fee/KEY1": {Key: "fee/KEY1", Action: NodeUnchanged, OldVersion: 2, NewVersion: 2, Status: ReportOK},
+		"fee/KEY2": {Key: "fee/KEY2", Action: NodeUpdated, OldVersion: 2, NewVersion: 3, Status: ReportOK},
+		"fee/KEY3": {Key: "fee/KEY3", Action: NodeCreated, NewVersion: 1, Status: ReportOK},
+	}, items)
+	assert.Equal(t, map[string]int{"skipped": 1, "unchanged": 1, "updated": 1, "created": 1}, options.Report.Summary)
+	assert.Equal(t, ReportChanged, options.Report.Status)
+	assert.Equal(t, ExitChanged, options.Report.ExitCode())
+
+	var buf bytes.Buffer
+	require.NoError(t, options.Report.Write(&buf))
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, "changed", decoded["status"])
+	assert.Len(t, decoded["items"], 4)
+
+	// nothing to change
+	options.Report = NewReport("importConfig")
+	_, err = client.Import(ctx, config, options)
+	require.NoError(t, err)
+	options.Report.Finish(nil)
+	assert.Equal(t, ReportNoChanges, options.Report.Status)
+	assert.Equal(t, ExitNoChanges, options.Report.ExitCode())
+
+	// failed plan item
+	options.Report = NewReport("importConfig")
+	plan := &Plan{Root: "importConfig", Items: []PlanItem{
+		{Key: "fee/LOCKED", Action: PlanDelete, OldType: "text/plain", OldValue: "3", Version: 2},
+	}}
+	err = client.ApplyPlan(ctx, plan, options)
+	require.ErrorIs(t, err, ErrForbidden)
+	options.Report.Finish(err)
+	require.Len(t, options.Report.Items, 1)
+	assert.Equal(t, NodeDeleted, options.Report.Items[0].Action)
+	assert.Equal(t, 2, options.Report.Items[0].OldVersion)
+	assert.Equal(t, ReportFailed, options.Report.Items[0].Status)
+	assert.NotEmpty(t, options.Report.Items[0].Error)
+	assert.Equal(t, map[string]int{"failed": 1}, options.Report.Summary)
+	assert.Equal(t, ReportFailed, options.Report.Status)
+	assert.Equal(t, ExitFailed, options.Report.ExitCode())
+}
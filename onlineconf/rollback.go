@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"onlineconf-yaml/yml/parser"
+	"strings"
+	"sync"
+)
+
+// Change node changed by the import, enough to revert it
+type Change struct {
+	Key    string
+	Action PlanAction
+	// Mime, Data and Meta before the update or delete
+	Mime string
+	Data string
+	Meta parser.NodeMeta
+	// Version after the create or update
+	Version int
+
+	managed bool
+}
+
+// Changes nodes changed by the import in order, safe for concurrent use
+type Changes struct {
+	mu      sync.Mutex
+	changes []Change
+}
+
+// Len number of changed nodes
+func (changes *Changes) Len() int {
+	changes.mu.Lock()
+	defer changes.mu.Unlock()
+	return len(changes.changes)
+}
+
+func (changes *Changes) add(change Change) {
+	changes.mu.Lock()
+	defer changes.mu.Unlock()
+	changes.changes = append(changes.changes, change)
+}
+
+// changed remember the change to roll back, must be called before LastApplied is updated
+func (options ImportOptions) changed(change Change) {
+	if options.Changes == nil {
+		return
+	}
+	if options.LastApplied != nil {
+		_, change.managed = options.LastApplied.Get(change.Key)
+	}
+	options.Changes.add(change)
+}
+
+// Rollback revert changes in reverse order: delete created nodes, restore data, mime and metadata of updated and deleted ones,
+// a node changed by someone else after the import is not reverted
+func (client *OnlineConfClient) Rollback(ctx context.Context, changes *Changes, options ImportOptions) error {
+	changes.mu.Lock()
+	list := append([]Change{}, changes.changes...)
+	changes.mu.Unlock()
+
+	var (
+		failed   []string
+		firstErr error
+	)
+	for i := len(list) - 1; i >= 0; i-- {
+		change := list[i]
+		client.logf(LogInfo, "rollback %s key: %+v\n", change.Action, change.Key)
+
+		var err error
+		switch change.Action {
+		case PlanCreate:
+			err = client.deleteNode(ctx, change.Key, change.Version, options.Comment)
+			if err == nil && options.LastApplied != nil {
+				options.LastApplied.Delete(change.Key)
+			}
+		case PlanUpdate, PlanDelete:
+			version := change.Version
+			if change.Action == PlanDelete {
+				version = 0
+			}
+			version, err = client.setNode(ctx, change.Key, change.Mime, change.Data, change.Meta, version, options.Comment)
+			if err == nil && options.LastApplied != nil {
+				if change.managed {
+					options.LastApplied.Set(change.Key, change.Mime, change.Data, version)
+				} else {
+					options.LastApplied.Delete(change.Key)
+				}
+			}
+		}
+		if err != nil {
+			client.logf(LogError, "ERROR: rollback key %s: %+v\n", change.Key, err)
+			failed = append(failed, change.Key)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("can't roll back %d of %d nodes: %s...%w", len(failed), len(list), strings.Join(failed, ", "), firstErr)
+	}
+	return nil
+}
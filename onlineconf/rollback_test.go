@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"onlineconf-yaml/onlineconf/onlineconftest"
+	"onlineconf-yaml/state"
+	"onlineconf-yaml/yml/parser"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollback(t *testing.T) {
+
+	server := onlineconftest.NewServer()
+	defer server.Close()
+	server.SetNode("importConfig/fee/KEY1", "text/plain", "1")
+	server.SetNode("importConfig/fee/LOCKED", "text/plain", "3")
+	server.SetNode("importConfig/old", "text/plain", "x")
+	server.Deny("importConfig/fee/LOCKED")
+	paths := server.Paths()
+
+	client, err := NewOnlineConfClient(server.NodeURL("importConfig"), "", "")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	lastApplied := state.New("importConfig")
+	lastApplied.Set("fee/KEY1", "text/plain", "1", 1)
+	options := ImportOptions{
+		UpdateIfExists:   true,
+		SkipAlreadyExist: true,
+		LastApplied:      lastApplied,
+		Changes:          &Changes{},
+	}
+
+	config := map[string]parser.OnlineConfItem{
+		"fee/KEY1":   {Key: "fee/KEY1", Value: "2", Type: "text/plain"},
+		"fee/KEY2":   {Key: "fee/KEY2", Value: "2", Type: "text/plain"},
+		"fee/LOCKED": {Key: "fee/LOCKED", Value: "4", Type: "text/plain"},
+		"new/KEY4":   {Key: "new/KEY4", Value: "4", Type: "text/plain"},
+	}
+	_, err = client.Import(ctx, config, options)
+	require.ErrorIs(t, err, ErrForbidden)
+	assert.Equal(t, 3, options.Changes.Len())
+
+	err = client.Rollback(ctx, options.Changes, options)
+	require.NoError(t, err)
+	assert.Equal(t, paths, server.Paths())
+	versions := server.Versions("importConfig/fee/KEY1")
+	assert.Equal(t, "1", versions[len(versions)-1].Data)
+	entry, ok := lastApplied.Get("fee/KEY1")
+	require.True(t, ok)
+	assert.Equal(t, versions[len(versions)-1].Version, entry.Version)
+	assert.Equal(t, []string{"fee/KEY1"}, lastApplied.Keys())
+
+	// deleted node is created again
+	options.Changes = &Changes{}
+	plan := &Plan{Root: "importConfig", Items: []PlanItem{
+		{Key: "old", Action: PlanDelete, OldType: "text/plain", OldValue: "x", Version: len(server.Versions("importConfig/old"))},
+		{Key: "fee/KEY1", Action: PlanUpdate, Type: "text/plain", Value: "5", Version: 1},
+	}}
+	err = client.ApplyPlan(ctx, plan, options)
+	require.ErrorIs(t, err, ErrVersionConflict)
+	assert.NotContains(t, server.Paths(), "/importConfig/old")
+
+	err = client.Rollback(ctx, options.Changes, options)
+	require.NoError(t, err)
+	versions = server.Versions("importConfig/old")
+	assert.Equal(t, onlineconftest.Version{Version: 1, Data: "x", Mime: "text/plain"}, versions[len(versions)-1])
+
+	// node changed after the import is not reverted
+	options.Changes = &Changes{}
+	config = map[string]parser.OnlineConfItem{
+		"fee/KEY2": {Key: "fee/KEY2", Value: "2", Type: "text/plain"},
+	}
+	_, err = client.Import(ctx, config, options)
+	require.NoError(t, err)
+	server.SetNode("importConfig/fee/KEY2", "text/plain", "manual")
+	err = client.Rollback(ctx, options.Changes, options)
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	assert.Contains(t, server.Paths(), "/importConfig/fee/KEY2")
+}
+
+func TestDeleteNodesRollback(t *testing.T) {
+
+	server := onlineconftest.NewServer()
+	defer server.Close()
+	server.SetNode("importConfig/fee/KEY1", "text/plain", "1")
+	server.SetNode("importConfig/fee/LOCKED", "text/plain", "3")
+	server.Deny("importConfig/fee/LOCKED")
+	paths := server.Paths()
+
+	client, err := NewOnlineConfClient(server.NodeURL("importConfig"), "", "")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	options := ImportOptions{Changes: &Changes{}, Report: NewReport("importConfig")}
+	err = client.DeleteNodes(ctx, []string{"fee/MISSING", "fee/KEY1", "fee/LOCKED", "fee"}, options)
+	require.ErrorIs(t, err, ErrForbidden)
+	assert.Equal(t, 1, options.Changes.Len())
+	assert.NotContains(t, server.Paths(), "/importConfig/fee/KEY1")
+	assert.Equal(t, 1, options.Report.Summary[string(NodeSkipped)])
+	assert.Equal(t, 1, options.Report.Summary[string(NodeDeleted)])
+
+	err = client.Rollback(ctx, options.Changes, options)
+	require.NoError(t, err)
+	assert.Equal(t, paths, server.Paths())
+	versions := server.Versions("importConfig/fee/KEY1")
+	assert.Equal(t, "1", versions[len(versions)-1].Data)
+}
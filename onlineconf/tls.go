@@ -0,0 +1,59 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// TLSOptions tls settings of the onlineconf connection
+type TLSOptions struct {
+	// CAFilepath PEM bundle of trusted CAs, system pool if empty
+	CAFilepath string
+	// CertFilepath and KeyFilepath PEM client certificate and key for mutual TLS
+	CertFilepath string
+	KeyFilepath  string
+	// Insecure skip server certificate verification
+	Insecure bool
+}
+
+// NewTLSConfig create tls config, server certificate is verified unless insecure is set
+func NewTLSConfig(options TLSOptions) (*tls.Config, error) {
+	config := &tls.Config{InsecureSkipVerify: options.Insecure}
+
+	if options.CAFilepath != "" {
+		pem, err := os.ReadFile(options.CAFilepath)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in the '%s'", options.CAFilepath)
+		}
+		config.RootCAs = pool
+	}
+
+	if options.CertFilepath != "" || options.KeyFilepath != "" {
+		if options.CertFilepath == "" || options.KeyFilepath == "" {
+			return nil, fmt.Errorf("both client certificate and key are required")
+		}
+		cert, err := tls.LoadX509KeyPair(options.CertFilepath, options.KeyFilepath)
+		if err != nil {
+			return nil, fmt.Errorf("can't load client certificate...%s", err.Error())
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// WithTLSConfig use the tls config for connections
+func WithTLSConfig(config *tls.Config) Option {
+	return func(client *OnlineConfClient) {
+		if transport, ok := client.httpClient.Transport.(*http.Transport); ok {
+			transport.TLSClientConfig = config
+		}
+	}
+}
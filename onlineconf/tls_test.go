@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTLSConfig(t *testing.T) {
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name":"KEY1","data":"4","mime":"text/plain","version":1}`))
+	}))
+	defer server.Close()
+
+	caFilepath := filepath.Join(t.TempDir(), "ca.pem")
+	err := os.WriteFile(caFilepath, pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: server.Certificate().Raw,
+	}), 0600)
+	require.NoError(t, err)
+
+	cases := []struct {
+		name    string
+		options TLSOptions
+		success bool
+	}{
+		{name: "verify by default", options: TLSOptions{}, success: false},
+		{name: "insecure", options: TLSOptions{Insecure: true}, success: true},
+		{name: "custom ca", options: TLSOptions{CAFilepath: caFilepath}, success: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tlsConfig, err := NewTLSConfig(c.options)
+			require.NoError(t, err)
+
+			client, err := NewOnlineConfClient(server.URL, "", "", WithRetries(0, 0), WithTLSConfig(tlsConfig))
+			require.NoError(t, err)
+
+			_, err = client.GetNode(context.Background(), "KEY1")
+			if c.success {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+
+	_, err = NewTLSConfig(TLSOptions{CertFilepath: caFilepath})
+	assert.Error(t, err)
+	_, err = NewTLSConfig(TLSOptions{CAFilepath: filepath.Join(t.TempDir(), "missing.pem")})
+	assert.Error(t, err)
+}
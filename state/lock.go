@@ -0,0 +1,41 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Lock exclusive lock of the state file
+type Lock struct {
+	filepath string
+}
+
+// AcquireLock lock the state file, waits up to timeout for another process to release it
+func AcquireLock(filepath string, timeout time.Duration) (*Lock, error) {
+	lockFilepath := filepath + ".lock"
+	deadline := time.Now().Add(timeout)
+	for {
+		file, err := os.OpenFile(lockFilepath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			fmt.Fprintf(file, "%d\n", os.Getpid())
+			file.Close()
+			return &Lock{filepath: lockFilepath}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			owner, _ := os.ReadFile(lockFilepath)
+			return nil, fmt.Errorf("state file '%s' is locked by process %s, remove the lock file if the process is dead", filepath, strings.TrimSpace(string(owner)))
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// Release unlock the state file
+func (lock *Lock) Release() error {
+	return os.Remove(lock.filepath)
+}
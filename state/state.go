@@ -0,0 +1,145 @@
+// Package state keeps node values last applied to OnlineConf
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FormatVersion version of the state file format
+const FormatVersion = 1
+
+// Entry last applied node value
+type Entry struct {
+	Mime    string    `json:"mime"`
+	Hash    string    `json:"hash"`
+	Version int       `json:"version"`
+	Applied time.Time `json:"applied"`
+}
+
+// State last applied values by key, safe for concurrent use
+type State struct {
+	mu      sync.Mutex
+	root    string
+	entries map[string]Entry
+}
+
+type stateFile struct {
+	Format  int              `json:"format"`
+	Root    string           `json:"root"`
+	Entries map[string]Entry `json:"entries"`
+}
+
+// New create empty state of the root node
+func New(root string) *State {
+	return &State{
+		root:    root,
+		entries: map[string]Entry{},
+	}
+}
+
+// Load read state of the root node from the file, empty state if the file does not exist
+func Load(filepath string, root string) (*State, error) {
+	state := New(root)
+	data, err := os.ReadFile(filepath)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file stateFile
+	err = json.Unmarshal(data, &file)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse state file '%s'... %s", filepath, err.Error())
+	}
+	if file.Format != FormatVersion {
+		return nil, fmt.Errorf("unsupported state file '%s' format %d, expected %d", filepath, file.Format, FormatVersion)
+	}
+	if file.Root != root {
+		return nil, fmt.Errorf("state file '%s' is made for the node '%s', not '%s'", filepath, file.Root, root)
+	}
+	if file.Entries != nil {
+		state.entries = file.Entries
+	}
+	return state, nil
+}
+
+// Save write state to the file atomically
+func (state *State) Save(filepath string) error {
+	state.mu.Lock()
+	data, err := json.MarshalIndent(stateFile{
+		Format:  FormatVersion,
+		Root:    state.root,
+		Entries: state.entries,
+	}, "", "  ")
+	state.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	tmpFilepath := filepath + ".tmp"
+	err = os.WriteFile(tmpFilepath, data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpFilepath, filepath)
+}
+
+// Get last applied value of the key
+func (state *State) Get(key string) (Entry, bool) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	entry, ok := state.entries[key]
+	return entry, ok
+}
+
+// Set remember applied value and OnlineConf version of the key
+func (state *State) Set(key string, mime string, value string, version int) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.entries[key] = Entry{
+		Mime:    mime,
+		Hash:    Hash(value),
+		Version: version,
+		Applied: time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+// Delete forget the key
+func (state *State) Delete(key string) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	delete(state.entries, key)
+}
+
+// Keys all keys, sorted
+func (state *State) Keys() []string {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	keys := make([]string, 0, len(state.entries))
+	for key := range state.entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Matches value is the last applied one
+func (entry Entry) Matches(mime string, value string) bool {
+	return entry.Mime == mime && entry.Hash == Hash(value)
+}
+
+// Hash hash of the node value
+func Hash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
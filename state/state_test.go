@@ -0,0 +1,64 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestState(t *testing.T) {
+
+	stateFilepath := filepath.Join(t.TempDir(), "importConfig.state")
+
+	state, err := Load(stateFilepath, "importConfig")
+	require.NoError(t, err)
+	assert.Empty(t, state.Keys())
+
+	state.Set("fee/KEY1", "text/plain", "4", 2)
+	state.Set("fee", "application/x-null", "", 1)
+	state.Set("fee/KEY2", "text/plain", "5", 1)
+	state.Delete("fee/KEY2")
+	require.NoError(t, state.Save(stateFilepath))
+
+	state, err = Load(stateFilepath, "importConfig")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fee", "fee/KEY1"}, state.Keys())
+
+	entry, ok := state.Get("fee/KEY1")
+	require.True(t, ok)
+	assert.Equal(t, 2, entry.Version)
+	assert.True(t, entry.Matches("text/plain", "4"))
+	assert.False(t, entry.Matches("text/plain", "5"))
+	assert.False(t, entry.Matches("application/x-yaml", "4"))
+	assert.WithinDuration(t, time.Now(), entry.Applied, time.Minute)
+
+	_, err = Load(stateFilepath, "otherConfig")
+	assert.Error(t, err)
+
+	require.NoError(t, os.WriteFile(stateFilepath, []byte(`{"format":2,"root":"importConfig"}`), 0644))
+	_, err = Load(stateFilepath, "importConfig")
+	assert.Error(t, err)
+}
+
+func TestLock(t *testing.T) {
+
+	stateFilepath := filepath.Join(t.TempDir(), "importConfig.state")
+
+	lock, err := AcquireLock(stateFilepath, 0)
+	require.NoError(t, err)
+
+	_, err = AcquireLock(stateFilepath, 200*time.Millisecond)
+	assert.Error(t, err)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		lock.Release()
+	}()
+	next, err := AcquireLock(stateFilepath, time.Second)
+	require.NoError(t, err)
+	assert.NoError(t, next.Release())
+}
@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// DocumentsMode how documents of the multi-document yml config are combined
+type DocumentsMode string
+
+// documents modes
+const (
+	// DocumentsMerge documents are merged in order, later documents override keys of earlier ones
+	DocumentsMerge DocumentsMode = "merge"
+	// DocumentsSeparate every document is the root node named by its index, starting from 0
+	DocumentsSeparate DocumentsMode = "separate"
+)
+
+// ParseDocumentsMode documents mode by name
+func ParseDocumentsMode(mode string) (DocumentsMode, error) {
+	switch DocumentsMode(mode) {
+	case DocumentsMerge, DocumentsSeparate:
+		return DocumentsMode(mode), nil
+	}
+	return "", fmt.Errorf("unknown documents mode '%s', expected merge or separate", mode)
+}
+
+// GetYMLDocuments get yml config of every document in the file combined by the mode, includes are resolved,
+// only values tagged as env are interpolated
+//
+// Deprecated: use GetYMLLayers and WalkByYMLNode.
+func GetYMLDocuments(filepath string, mode DocumentsMode) (interface{}, error) {
+	layers, err := GetYMLLayers([]string{filepath}, mode, false)
+	if err != nil {
+		return nil, err
+	}
+	return layers.Data()
+}
+
+// combineDocuments merge documents or put every document to the root node named by its index,
+// nil if there are no documents
+func combineDocuments(documents []*yamlv3.Node, mode DocumentsMode) (*yamlv3.Node, error) {
+	switch mode {
+	case DocumentsMerge, "":
+		var data *yamlv3.Node
+		for _, document := range documents {
+			data = mergeYMLNodes(data, documentContent(document))
+		}
+		return data, nil
+	case DocumentsSeparate:
+		data := &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+		for i, document := range documents {
+			if content := documentContent(document); content != nil {
+				key := &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: strconv.Itoa(i)}
+				data.Content = append(data.Content, key, content)
+			}
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("unknown documents mode '%s'", mode)
+}
+
+// decodeYMLNodes every document of the yml content as yaml.v3 node
+func decodeYMLNodes(content []byte) ([]*yamlv3.Node, error) {
+	documents := []*yamlv3.Node{}
+	decoder := yamlv3.NewDecoder(bytes.NewReader(content))
+	for {
+		var document yamlv3.Node
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("document %d... %w", len(documents), err)
+		}
+		documents = append(documents, &document)
+	}
+	return documents, nil
+}
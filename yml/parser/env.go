@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// EnvTag yaml tag of the value taken from the environment variable, e.g. `host: !env DB_HOST`
+// or `host: !env DB_HOST:-localhost`
+const EnvTag = "!env"
+
+// ErrUnresolvedVariable environment variable of the config is not set and has no default
+var ErrUnresolvedVariable = errors.New("unresolved variables")
+
+// variablePattern ${VAR} or ${VAR:-default}, $$ is the escaped dollar
+var variablePattern = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// interpolate replace values tagged as env and, if variables is set, variables in scalar values of the node,
+// returns ErrUnresolvedVariable listing every missing variable with the key of its node
+func interpolate(node *yamlv3.Node, variables bool, lookup func(name string) (string, bool)) error {
+	missing := []string{}
+	interpolateNode(node, "", variables, lookup, &missing)
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrUnresolvedVariable, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// interpolateNode keys are built like WalkByYMLNode does: value and metadata of the leaf belong to the leaf,
+// lists are single nodes
+func interpolateNode(node *yamlv3.Node, key string, variables bool, lookup func(name string) (string, bool), missing *[]string) {
+	switch node.Kind {
+	case yamlv3.ScalarNode:
+		switch {
+		case node.Tag == EnvTag:
+			node.Value = expandVariables("${"+node.Value+"}", key, lookup, missing)
+			node.Style &^= yamlv3.TaggedStyle
+			node.Tag = resolveTag(node)
+		case variables && strings.Contains(node.Value, "$"):
+			node.Value = expandVariables(node.Value, key, lookup, missing)
+			if node.Tag != SecretTag {
+				node.Tag = resolveTag(node)
+			}
+		}
+	case yamlv3.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			name, value := node.Content[i].Value, node.Content[i+1]
+			switch {
+			case key != "" && name == ValueKey:
+				interpolateNode(value, key, variables, lookup, missing)
+			case key != "" && name == MetaKey && value.Kind == yamlv3.MappingNode:
+				for j := 1; j < len(value.Content); j += 2 {
+					interpolateNode(value.Content[j], key, variables, lookup, missing)
+				}
+			default:
+				interpolateNode(value, joinKey(key, name), variables, lookup, missing)
+			}
+		}
+	default:
+		for _, child := range node.Content {
+			interpolateNode(child, key, variables, lookup, missing)
+		}
+	}
+}
+
+// resolveTag tag of the interpolated scalar resolved like the parser resolves the written one,
+// e.g. plain 9090 is int and '9090' is str, so every walker reads the same value
+func resolveTag(node *yamlv3.Node) string {
+	resolved := *node
+	resolved.Tag = ""
+	return resolved.ShortTag()
+}
+
+// expandVariables replace variables of the value, missing ones are added to the list
+func expandVariables(value string, key string, lookup func(name string) (string, bool), missing *[]string) string {
+	return variablePattern.ReplaceAllStringFunc(value, func(match string) string {
+		if match == "$$" {
+			return "$"
+		}
+		groups := variablePattern.FindStringSubmatch(match)
+		if value, ok := lookup(groups[1]); ok && (value != "" || groups[2] == "") {
+			return value
+		}
+		if groups[2] != "" {
+			return groups[3]
+		}
+		*missing = append(*missing, fmt.Sprintf("%s at %s", groups[1], key))
+		return ""
+	})
+}
+
+// lookupEnv environment variables of the process
+func lookupEnv(name string) (string, bool) {
+	return os.LookupEnv(name)
+}
@@ -0,0 +1,126 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// IncludeTag yaml tag of the included file, e.g. `fees: !include fees.yml`, the path is relative to the including file,
+// the glob form `!include teams/*.yml` merges matching files in name order
+const IncludeTag = "!include"
+
+// ErrIncludeCycle file includes itself directly or through other files
+var ErrIncludeCycle = errors.New("include cycle")
+
+// loadYMLNodes documents of the yml file with resolved includes
+func loadYMLNodes(path string) ([]*yamlv3.Node, error) {
+	return loadIncludedNodes(path, nil)
+}
+
+// loadIncludedNodes documents of the file included by the chain of files, aliases are expanded
+func loadIncludedNodes(path string, chain []string) ([]*yamlv3.Node, error) {
+	chain = append(chain[:len(chain):len(chain)], path)
+	for _, included := range chain[:len(chain)-1] {
+		if sameFile(included, path) {
+			return nil, includeError(chain, ErrIncludeCycle)
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, includeError(chain, err)
+	}
+	documents, err := decodeYMLNodes(content)
+	if err != nil {
+		return nil, includeError(chain, err)
+	}
+	for _, document := range documents {
+		expandAliases(document)
+		err = resolveIncludes(document, chain)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return documents, nil
+}
+
+// resolveIncludes replace nodes tagged as included with the content of the files
+func resolveIncludes(node *yamlv3.Node, chain []string) error {
+	if node.Tag != IncludeTag {
+		for _, child := range node.Content {
+			err := resolveIncludes(child, chain)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if node.Kind != yamlv3.ScalarNode || node.Value == "" {
+		return includeError(chain, fmt.Errorf("line %d: %s value must be a file path or glob", node.Line, IncludeTag))
+	}
+	pattern := node.Value
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(filepath.Dir(chain[len(chain)-1]), pattern)
+	}
+	paths := []string{pattern}
+	if strings.ContainsAny(node.Value, "*?[") {
+		var err error
+		paths, err = filepath.Glob(pattern)
+		if err != nil {
+			return includeError(chain, fmt.Errorf("line %d: bad glob '%s'... %w", node.Line, node.Value, err))
+		}
+		sort.Strings(paths)
+	}
+
+	included := &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+	for _, path := range paths {
+		documents, err := loadIncludedNodes(path, chain)
+		if err != nil {
+			return err
+		}
+		for _, document := range documents {
+			included = mergeYMLNodes(included, documentContent(document))
+		}
+	}
+	*node = *included
+	return nil
+}
+
+// documentContent root node of the document, nil if the document is empty
+func documentContent(node *yamlv3.Node) *yamlv3.Node {
+	if node.Kind == yamlv3.DocumentNode {
+		if len(node.Content) == 0 {
+			return nil
+		}
+		node = node.Content[0]
+	}
+	if node.Kind == yamlv3.ScalarNode && node.Tag == "!!null" {
+		return nil
+	}
+	return node
+}
+
+// sameFile paths point to the same file
+func sameFile(a, b string) bool {
+	aInfo, aErr := os.Stat(a)
+	bInfo, bErr := os.Stat(b)
+	if aErr != nil || bErr != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return os.SameFile(aInfo, bInfo)
+}
+
+// includeError error of the file with the chain of files including it
+func includeError(chain []string, err error) error {
+	if len(chain) == 1 {
+		return err
+	}
+	return fmt.Errorf("%s (included by %s)... %w", chain[len(chain)-1], strings.Join(chain[:len(chain)-1], " -> "), err)
+}
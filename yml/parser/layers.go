@@ -0,0 +1,221 @@
+package parser
+
+import (
+	"log"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// operator tags of the overlay layers, without operator maps are merged deeply and other values are replaced:
+//
+//	fee: !replace
+//	  KEY1: 4
+//	hosts: !append
+//	  - backup.local
+//	legacy: !delete
+const (
+	ReplaceTag = "!replace"
+	AppendTag  = "!append"
+	DeleteTag  = "!delete"
+)
+
+// Layers config merged from the layer files in order, later layers override earlier ones
+type Layers struct {
+	// Node merged yml config to walk by WalkByYMLNode, nil if the config is empty
+	Node *yamlv3.Node
+	// SecretKeys keys of nodes tagged as secret in any layer
+	SecretKeys []string
+
+	origins map[string]string
+}
+
+// Origin layer file the node came from, empty if the node is unknown,
+// children list stored by WalkByYMLNode with storeNodes comes from the layer of its map
+func (layers *Layers) Origin(key string) string {
+	return layers.origins[strings.TrimSuffix(key, ".")]
+}
+
+// Data merged yml config to walk by WalkByYML, the merged config is parsed by yaml.v2 like a single file,
+// nil if the config is empty
+//
+// Deprecated: walk Node by WalkByYMLNode, it keeps the text written in the config.
+func (layers *Layers) Data() (interface{}, error) {
+	if layers.Node == nil {
+		return nil, nil
+	}
+	content, err := yamlv3.Marshal(layers.Node)
+	if err != nil {
+		return nil, err
+	}
+	var data interface{}
+	err = yaml.Unmarshal(content, &data)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetYMLLayers get yml config of the layer files merged in order, e.g. base.yml and production.yml,
+// documents of every layer are combined by the mode, includes are resolved and values of the merged config tagged as env
+// are replaced, variables in other values are replaced only if interpolateVariables is set
+func GetYMLLayers(filepaths []string, mode DocumentsMode, interpolateVariables bool) (*Layers, error) {
+	var (
+		merged     *yamlv3.Node
+		nodeLayers = map[*yamlv3.Node]string{}
+		secretKeys = map[string]bool{}
+	)
+	for _, filepath := range filepaths {
+		documents, err := loadYMLNodes(filepath)
+		if err != nil {
+			return nil, err
+		}
+		collectSecretKeys(documents, mode, secretKeys)
+
+		layer, err := combineDocuments(documents, mode)
+		if err != nil {
+			return nil, err
+		}
+		markLayer(layer, filepath, nodeLayers)
+		merged = mergeYMLNodes(merged, layer)
+	}
+
+	layers := &Layers{SecretKeys: []string{}, origins: map[string]string{}}
+	for key := range secretKeys {
+		layers.SecretKeys = append(layers.SecretKeys, key)
+	}
+	sort.Strings(layers.SecretKeys)
+	if merged == nil {
+		return layers, nil
+	}
+
+	stripOperators(merged)
+	err := interpolate(merged, interpolateVariables, lookupEnv)
+	if err != nil {
+		return nil, err
+	}
+	walkOrigins(merged, "", nodeLayers, layers.origins)
+	layers.Node = merged
+	return layers, nil
+}
+
+// mergeYMLNodes deep merge of mappings honouring operator tags of b, other nodes of b replace a, empty b keeps a,
+// merged nodes are built in place of b
+func mergeYMLNodes(a, b *yamlv3.Node) *yamlv3.Node {
+	if b == nil {
+		return a
+	}
+	if a == nil || b.Tag == ReplaceTag {
+		return b
+	}
+	if b.Tag == AppendTag {
+		if a.Kind != yamlv3.SequenceNode || b.Kind != yamlv3.SequenceNode {
+			log.Printf("Appended value at line %d is not a list, it replaces the inherited value", b.Line)
+			return b
+		}
+		b.Content = append(append([]*yamlv3.Node{}, a.Content...), b.Content...)
+		return b
+	}
+	if a.Kind != yamlv3.MappingNode || b.Kind != yamlv3.MappingNode {
+		return b
+	}
+
+	content := append([]*yamlv3.Node{}, a.Content...)
+	for i := 0; i+1 < len(b.Content); i += 2 {
+		key, value := b.Content[i], b.Content[i+1]
+		found := -1
+		for j := 0; j+1 < len(content); j += 2 {
+			if content[j].Value == key.Value {
+				found = j
+				break
+			}
+		}
+		switch {
+		case found < 0:
+			content = append(content, key, value)
+		case value.Tag == DeleteTag:
+			content = append(content[:found], content[found+2:]...)
+		default:
+			content[found+1] = mergeYMLNodes(content[found+1], value)
+		}
+	}
+	b.Content = content
+	return b
+}
+
+// expandAliases replace aliases with copies of their anchored nodes and drop anchors,
+// so merged nodes built in place of the overlay don't leave aliases without anchors
+func expandAliases(node *yamlv3.Node) {
+	node.Anchor = ""
+	for i, child := range node.Content {
+		if child.Kind == yamlv3.AliasNode {
+			node.Content[i] = copyYMLNode(resolveAlias(child))
+		}
+		expandAliases(node.Content[i])
+	}
+}
+
+// copyYMLNode deep copy of the node
+func copyYMLNode(node *yamlv3.Node) *yamlv3.Node {
+	copied := *node
+	if node.Content != nil {
+		copied.Content = make([]*yamlv3.Node, len(node.Content))
+		for i, child := range node.Content {
+			copied.Content[i] = copyYMLNode(child)
+		}
+	}
+	return &copied
+}
+
+// stripOperators remove deleted keys and operator tags left after the merge
+func stripOperators(node *yamlv3.Node) {
+	if node.Tag == ReplaceTag || node.Tag == AppendTag {
+		node.Tag = ""
+	}
+	if node.Kind == yamlv3.MappingNode {
+		content := node.Content[:0]
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i+1].Tag != DeleteTag {
+				content = append(content, node.Content[i], node.Content[i+1])
+			}
+		}
+		node.Content = content
+	}
+	for _, child := range node.Content {
+		stripOperators(child)
+	}
+}
+
+// markLayer remember the layer file of every node
+func markLayer(node *yamlv3.Node, filepath string, nodeLayers map[*yamlv3.Node]string) {
+	if node == nil {
+		return
+	}
+	nodeLayers[node] = filepath
+	for _, child := range node.Content {
+		markLayer(child, filepath, nodeLayers)
+	}
+}
+
+// walkOrigins layer files of the node keys like WalkByYMLNode builds them: value of the leaf with metadata
+// comes from the _value node, maps and lists are single nodes
+func walkOrigins(node *yamlv3.Node, prefix string, nodeLayers map[*yamlv3.Node]string, origins map[string]string) {
+	if prefix != "" {
+		origins[prefix] = nodeLayers[node]
+	}
+	node = resolveAlias(node)
+	if node.Kind != yamlv3.MappingNode {
+		return
+	}
+	for _, pair := range mappingPairs(node) {
+		switch {
+		case pair.key == MetaKey:
+		case pair.key == ValueKey && prefix != "":
+			origins[prefix] = nodeLayers[pair.value]
+		default:
+			walkOrigins(pair.value, joinKey(prefix, pair.key), nodeLayers, origins)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package parser
+
+// reserved keys of the node with metadata:
+//
+//	fee:
+//	  _meta:
+//	    summary: Fees
+//	  KEY1:
+//	    _value: 4
+//	    _meta:
+//	      description: Fee of the KEY1
+//	      notification: with-value
+const (
+	MetaKey  = "_meta"
+	ValueKey = "_value"
+)
+
+// NodeMeta onlineconf node metadata, empty fields are not managed by the import
+type NodeMeta struct {
+	Summary      string `json:"summary,omitempty"`
+	Description  string `json:"description,omitempty"`
+	Notification string `json:"notification,omitempty"`
+}
+
+// IsEmpty metadata is not set
+func (meta NodeMeta) IsEmpty() bool {
+	return meta == NodeMeta{}
+}
@@ -0,0 +1,234 @@
+package parser
+
+import (
+	"encoding/json"
+	"log"
+	"sort"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// WalkByYMLNode walk by yml node and flatten it to onlineconf nodes, scalar values keep the text written in the config,
+// e.g. 1.10, 1e6 or 0x1F, values of lists keep the scalar text and quoting
+func WalkByYMLNode(node *yamlv3.Node, prefix string, storeNodes bool) map[string]OnlineConfItem {
+	o := make(map[string]OnlineConfItem)
+	if node == nil {
+		return o
+	}
+	switch node.Kind {
+	case yamlv3.DocumentNode:
+		for _, child := range node.Content {
+			o = mergeMaps(o, WalkByYMLNode(child, prefix, storeNodes))
+		}
+	case yamlv3.AliasNode:
+		o = mergeMaps(o, WalkByYMLNode(node.Alias, prefix, storeNodes))
+	case yamlv3.MappingNode:
+		pairs := mappingPairs(node)
+		meta := NodeMeta{}
+		if value, ok := pairValue(pairs, MetaKey); ok {
+			meta = parseMetaNode(value, prefix)
+		}
+		if value, ok := pairValue(pairs, ValueKey); ok && prefix != "" {
+			// leaf node with metadata
+			res := WalkByYMLNode(value, prefix, storeNodes)
+			if item, ok := res[prefix]; ok {
+				item.Meta = meta
+				res[prefix] = item
+			} else if !meta.IsEmpty() {
+				log.Printf("Value of the '%s' is not a scalar or a list, its metadata is skipped", prefix)
+			}
+			o = mergeMaps(o, res)
+			break
+		}
+
+		childrenKeys := []string{}
+		for _, pair := range pairs {
+			if pair.key != MetaKey {
+				childrenKeys = append(childrenKeys, pair.key)
+			}
+		}
+		if prefix != "" {
+			if len(childrenKeys) == 0 {
+				o[prefix] = OnlineConfItem{
+					Key:   prefix,
+					Value: "{}",
+					Type:  "application/x-yaml",
+					Meta:  meta,
+				}
+				break
+			}
+			if !meta.IsEmpty() && !storeNodes {
+				// parent node with metadata
+				o[prefix] = OnlineConfItem{
+					Key:  prefix,
+					Type: "application/x-null",
+					Meta: meta,
+				}
+			}
+			if storeNodes {
+				sort.Strings(childrenKeys)
+				jsonBytes, err := json.Marshal(childrenKeys)
+				if err != nil {
+					log.Printf("Can't marshal node keys for the '%s': %s", prefix, err.Error())
+					break
+				}
+				o[prefix+"."] = OnlineConfItem{
+					Key:   prefix + ".",
+					Value: string(jsonBytes),
+					Type:  "application/x-yaml",
+				}
+			}
+		}
+		for _, pair := range pairs {
+			if pair.key == MetaKey {
+				continue
+			}
+			o = mergeMaps(o, WalkByYMLNode(pair.value, joinKey(prefix, pair.key), storeNodes))
+		}
+	case yamlv3.SequenceNode:
+		list := []string{}
+		for _, item := range node.Content {
+			item = untagged(item)
+			text, err := encodeYMLNode(&yamlv3.Node{Kind: yamlv3.SequenceNode, Content: []*yamlv3.Node{item}})
+			if err != nil {
+				log.Fatal(err)
+			}
+			if item.Kind == yamlv3.ScalarNode {
+				text = strings.TrimSuffix(text, "\n")
+			}
+			list = append(list, text)
+		}
+		if len(list) > 0 {
+			o[prefix] = OnlineConfItem{
+				Key:   prefix,
+				Value: strings.Join(list, "\n"),
+				Type:  "application/x-yaml",
+			}
+		}
+	case yamlv3.ScalarNode:
+		if node.Tag == "!!null" {
+			break
+		}
+		o[prefix] = OnlineConfItem{
+			Key:   prefix,
+			Value: node.Value,
+			Type:  "text/plain",
+		}
+	}
+	return o
+}
+
+// nodePair key and value of the mapping
+type nodePair struct {
+	key   string
+	value *yamlv3.Node
+}
+
+// mappingPairs keys and values of the mapping, keys of the << merge come first and are overridden by the mapping keys
+func mappingPairs(node *yamlv3.Node) []nodePair {
+	pairs := []nodePair{}
+	index := map[string]int{}
+	add := func(key string, value *yamlv3.Node) {
+		if i, ok := index[key]; ok {
+			pairs[i].value = value
+			return
+		}
+		index[key] = len(pairs)
+		pairs = append(pairs, nodePair{key: key, value: value})
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Tag != "!!merge" {
+			continue
+		}
+		merged := []*yamlv3.Node{resolveAlias(node.Content[i+1])}
+		if merged[0].Kind == yamlv3.SequenceNode {
+			merged = merged[0].Content
+		}
+		for _, source := range merged {
+			source = resolveAlias(source)
+			if source.Kind != yamlv3.MappingNode {
+				continue
+			}
+			for _, pair := range mappingPairs(source) {
+				add(pair.key, pair.value)
+			}
+		}
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Tag != "!!merge" {
+			add(node.Content[i].Value, node.Content[i+1])
+		}
+	}
+	return pairs
+}
+
+func pairValue(pairs []nodePair, key string) (*yamlv3.Node, bool) {
+	for _, pair := range pairs {
+		if pair.key == key {
+			return pair.value, true
+		}
+	}
+	return nil, false
+}
+
+func resolveAlias(node *yamlv3.Node) *yamlv3.Node {
+	for node.Kind == yamlv3.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+// parseMetaNode metadata of the _meta mapping, unknown fields are reported and skipped
+func parseMetaNode(node *yamlv3.Node, prefix string) NodeMeta {
+	meta := NodeMeta{}
+	node = resolveAlias(node)
+	if node.Kind != yamlv3.MappingNode {
+		log.Printf("Metadata of the '%s' is not a map", prefix)
+		return meta
+	}
+	for _, pair := range mappingPairs(node) {
+		value := resolveAlias(pair.value).Value
+		switch pair.key {
+		case "summary":
+			meta.Summary = value
+		case "description":
+			meta.Description = value
+		case "notification":
+			meta.Notification = value
+		default:
+			log.Printf("Unknown metadata field '%s' of the '%s'", pair.key, prefix)
+		}
+	}
+	return meta
+}
+
+// untagged copy of the node with expanded aliases and without custom tags like !secret,
+// so the node can be encoded on its own
+func untagged(node *yamlv3.Node) *yamlv3.Node {
+	node = resolveAlias(node)
+	copied := *node
+	copied.Anchor = ""
+	if strings.HasPrefix(copied.Tag, "!") && !strings.HasPrefix(copied.Tag, "!!") {
+		copied.Tag = ""
+	}
+	copied.Content = make([]*yamlv3.Node, len(node.Content))
+	for i, child := range node.Content {
+		copied.Content[i] = untagged(child)
+	}
+	return &copied
+}
+
+// encodeYMLNode yml text of the node with 2 spaces indent
+func encodeYMLNode(node *yamlv3.Node) (string, error) {
+	var text strings.Builder
+	encoder := yamlv3.NewEncoder(&text)
+	encoder.SetIndent(2)
+	err := encoder.Encode(node)
+	if err != nil {
+		return "", err
+	}
+	err = encoder.Close()
+	return text.String(), err
+}
@@ -1,17 +1,12 @@
 package parser
 
 import (
-	"bufio"
-	"encoding/json"
-	"fmt"
-	"io"
 	"log"
-	"os"
 	"reflect"
 	"sort"
 	"strings"
 
-	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
 )
 
 // OnlineConfItem onlineconf node
@@ -19,6 +14,7 @@ type OnlineConfItem struct {
 	Key   string
 	Value string
 	Type  string
+	Meta  NodeMeta
 }
 
 // GetParentNodeKeys getting parent node keys
@@ -81,127 +77,25 @@ func GetNodeKeysForDelete(config map[string]OnlineConfItem) []string {
 	return nodeKeys
 }
 
-// WalkByYML walk by yml
+// WalkByYML walk by yml data decoded by GetYMLConfig, the data is encoded to yml node and walked by WalkByYMLNode,
+// scalar values are formatted by Go, e.g. 1.10 becomes 1.1
+//
+// Deprecated: use WalkByYMLNode with the node of GetYMLLayers, it keeps the text written in the config.
 func WalkByYML(obj reflect.Value, prefix string, storeNodes bool) map[string]OnlineConfItem {
-	o := make(map[string]OnlineConfItem)
-	switch obj.Kind() {
-	case reflect.Ptr:
-		res := WalkByYML(obj.Elem(), "", storeNodes)
-		o = mergeMaps(o, res)
-	case reflect.Interface:
-		res := WalkByYML(obj.Elem(), prefix, storeNodes)
-		o = mergeMaps(o, res)
-	case reflect.Map:
-		if prefix != "" {
-			childrenKeys := []string{}
-			for _, key := range obj.MapKeys() {
-				p := key.Elem().String()
-				childrenKeys = append(childrenKeys, p)
-			}
-			nodePrefix := prefix + "."
-
-			if len(childrenKeys) == 0 {
-				o[prefix] = OnlineConfItem{
-					Key:   prefix,
-					Value: "{}",
-					Type:  "application/x-yaml",
-				}
-				break
-			}
-
-			if storeNodes {
-				sort.Strings(childrenKeys)
-				jsonBytes, err := json.Marshal(childrenKeys)
-				if err != nil {
-					log.Printf("Can't marshal node keys for the '%s': %s", prefix, err.Error())
-					break
-				}
-				o[nodePrefix] = OnlineConfItem{
-					Key:   nodePrefix,
-					Value: string(jsonBytes),
-					Type:  "application/x-yaml",
-				}
-			}
-		}
-		for _, key := range obj.MapKeys() {
-			p := key.Elem().String()
-			if prefix != "" {
-				//				keyValue := ""
-				//				switch key.Elem() {
-				//					case reflect.Int
-				//				}
-				p = fmt.Sprintf("%s/%v", prefix, key.Elem())
-				//				log.Printf("================> %+v", key.Elem())
-				//				log.Printf("================> %+v", key.Elem())
-			}
-			res := WalkByYML(obj.MapIndex(key), p, storeNodes)
-			//			o[prefix] = res
-			o = mergeMaps(o, res)
-		}
-	case reflect.Slice:
-		////		o[prefix] = obj.Interface()
-		//list := []interface{}{}
-		list := []string{}
-		for i := 0; i < obj.Len(); i++ {
-			sliceObj := obj.Index(i)
-			toMarshalIf := sliceObj.Interface()
-			switch v := toMarshalIf.(type) {
-			case string, float64, int, bool:
-				list = append(list, fmt.Sprintf("- %v", v))
-			default:
-				toMarshalIf = []interface{}{v}
-				yamlOption, err := yaml.Marshal(toMarshalIf)
-				if err != nil {
-					log.Fatal(fmt.Errorf("can't marshal %+v to yaml... %+v", sliceObj.Interface(), err))
-				}
-				list = append(list, fmt.Sprintf("%v", string(yamlOption)))
-			}
-		}
-		if len(list) > 0 {
-			o[prefix] = OnlineConfItem{
-				Key:   prefix,
-				Value: strings.Join(list, "\n"),
-				Type:  "application/x-yaml",
-			}
-		}
-	case reflect.String, reflect.Float64, reflect.Int, reflect.Bool:
-		o[prefix] = OnlineConfItem{
-			Key:   prefix,
-			Value: fmt.Sprintf("%v", obj.Interface()),
-			Type:  "text/plain",
-		}
+	var node yamlv3.Node
+	err := node.Encode(obj.Interface())
+	if err != nil {
+		log.Printf("Can't encode yml data of the '%s': %s", prefix, err.Error())
+		return map[string]OnlineConfItem{}
 	}
-	return o
+	return WalkByYMLNode(&node, prefix, storeNodes)
 }
 
-// GetYMLConfig get yml config
+// GetYMLConfig get yml config, documents of multi-document config are merged in order
+//
+// Deprecated: use GetYMLLayers and WalkByYMLNode.
 func GetYMLConfig(filepath string) (interface{}, error) {
-	var data interface{}
-
-	readFile, err := os.Open(filepath)
-	if err != nil {
-		return data, err
-	}
-	defer readFile.Close()
-	reader := bufio.NewReader(readFile)
-	yamlProcessed := ""
-	for {
-		line, err := reader.ReadString('\n')
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
-			return data, err
-		}
-		yamlProcessed += line
-	}
-
-	err = yaml.Unmarshal([]byte(yamlProcessed), &data)
-	if err != nil {
-		return data, err
-	}
-
-	return data, err
+	return GetYMLDocuments(filepath, DocumentsMerge)
 }
 
 func mergeMaps(a, b map[string]OnlineConfItem) map[string]OnlineConfItem {
@@ -210,3 +104,11 @@ func mergeMaps(a, b map[string]OnlineConfItem) map[string]OnlineConfItem {
 	}
 	return a
 }
+
+// joinKey key of the child node
+func joinKey(key string, name string) string {
+	if key == "" {
+		return name
+	}
+	return key + "/" + name
+}
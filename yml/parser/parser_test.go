@@ -3,7 +3,10 @@ package parser
 import (
 	"io"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -131,6 +134,425 @@ fee:
 	assert.Equal(t, true, reflect.DeepEqual(src, expected), "struct equals")
 }
 
+func TestGetYMLSecretKeys(t *testing.T) {
+
+	cfgFilepath, err := writeYMLConfig(`
+db:
+  host: localhost
+  password: !secret qwerty
+  replicas:
+    - host: replica
+      password: !secret qwerty
+tokens: !secret
+  github: abc
+wrapped:
+  _value: !secret s3cr3t
+  _meta:
+    summary: Wrapped secret
+defaults: &d
+  dbpass: !secret qwerty
+svc:
+  <<: *d
+`)
+	require.NoError(t, err)
+
+	keys, err := GetYMLSecretKeys(cfgFilepath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db/password", "db/replicas", "defaults/dbpass", "svc/dbpass", "tokens", "wrapped"}, keys)
+
+	config, err := walkYMLConfig(cfgFilepath, DocumentsMerge)
+	require.NoError(t, err)
+	assert.Equal(t, "qwerty", config["db/password"].Value)
+	assert.Equal(t, "abc", config["tokens/github"].Value)
+}
+
+func TestParseMeta(t *testing.T) {
+
+	cfgFilepath, err := writeYMLConfig(`
+fee:
+  _meta:
+    summary: Fees
+  KEY1:
+    _value: 4
+    _meta:
+      description: Fee of the KEY1
+      notification: with-value
+  KEY2:
+    _meta:
+      summary: Empty
+  KEY3: 2
+`)
+	require.NoError(t, err)
+
+	config, err := walkYMLConfig(cfgFilepath, DocumentsMerge)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]OnlineConfItem{
+		"fee": {Key: "fee", Type: "application/x-null", Meta: NodeMeta{Summary: "Fees"}},
+		"fee/KEY1": {Key: "fee/KEY1", Value: "4", Type: "text/plain",
+			Meta: NodeMeta{Description: "Fee of the KEY1", Notification: "with-value"}},
+		"fee/KEY2": {Key: "fee/KEY2", Value: "{}", Type: "application/x-yaml", Meta: NodeMeta{Summary: "Empty"}},
+		"fee/KEY3": {Key: "fee/KEY3", Value: "2", Type: "text/plain"},
+	}, config)
+}
+
+func TestGetYMLDocuments(t *testing.T) {
+
+	cfgFilepath, err := writeYMLConfig(`
+fee:
+  KEY1: 1
+  KEY2: 2
+  common:
+    R: 1
+---
+---
+fee:
+  KEY2: 3
+  common:
+    VR: 2
+token: !secret abc
+`)
+	require.NoError(t, err)
+
+	config, err := walkYMLConfig(cfgFilepath, DocumentsMerge)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]OnlineConfItem{
+		"fee/KEY1":      {Key: "fee/KEY1", Value: "1", Type: "text/plain"},
+		"fee/KEY2":      {Key: "fee/KEY2", Value: "3", Type: "text/plain"},
+		"fee/common/R":  {Key: "fee/common/R", Value: "1", Type: "text/plain"},
+		"fee/common/VR": {Key: "fee/common/VR", Value: "2", Type: "text/plain"},
+		"token":         {Key: "token", Value: "abc", Type: "text/plain"},
+	}, config)
+
+	config, err = walkYMLConfig(cfgFilepath, DocumentsSeparate)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0/fee/KEY1", "0/fee/KEY2", "0/fee/common/R", "2/fee/KEY2", "2/fee/common/VR", "2/token"}, sortedKeys(config))
+
+	keys, err := GetYMLDocumentsSecretKeys(cfgFilepath, DocumentsSeparate)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2/token"}, keys)
+
+	_, err = ParseDocumentsMode("first")
+	assert.Error(t, err)
+}
+
+func TestInclude(t *testing.T) {
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.yml": `
+fee: !include fee.yml
+teams: !include teams/*.yml
+`,
+		"fee.yml": `
+KEY1: 1
+common: !include common/r.yml
+`,
+		"common/r.yml":  "R: 1\n",
+		"teams/a.yml":   "a:\n  token: !secret abc\nshared: 1\n",
+		"teams/b.yml":   "b: 2\nshared: 2\n",
+		"cycle.yml":     "self: !include cycle2.yml\n",
+		"cycle2.yml":    "back: !include cycle.yml\n",
+		"missing.yml":   "fee: !include fee2.yml\n",
+		"fee2.yml":      "KEY: !include absent.yml\n",
+		"notScalar.yml": "fee: !include [fee.yml]\n",
+	}
+	for name, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	config, err := walkYMLConfig(filepath.Join(dir, "main.yml"), DocumentsMerge)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fee/KEY1", "fee/common/R", "teams/a/token", "teams/b", "teams/shared"}, sortedKeys(config))
+	assert.Equal(t, "2", config["teams/shared"].Value)
+
+	keys, err := GetYMLSecretKeys(filepath.Join(dir, "main.yml"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"teams/a/token"}, keys)
+
+	_, err = walkYMLConfig(filepath.Join(dir, "cycle.yml"), DocumentsMerge)
+	assert.ErrorIs(t, err, ErrIncludeCycle)
+	assert.Contains(t, err.Error(), "cycle.yml -> "+filepath.Join(dir, "cycle2.yml"))
+
+	_, err = walkYMLConfig(filepath.Join(dir, "missing.yml"), DocumentsMerge)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.Contains(t, err.Error(), "(included by "+filepath.Join(dir, "missing.yml")+" -> "+filepath.Join(dir, "fee2.yml")+")")
+
+	_, err = walkYMLConfig(filepath.Join(dir, "notScalar.yml"), DocumentsMerge)
+	assert.Error(t, err)
+}
+
+func TestGetYMLLayers(t *testing.T) {
+
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.yml")
+	production := filepath.Join(dir, "production.yml")
+	require.NoError(t, os.WriteFile(base, []byte(`
+fee:
+  KEY1: 1
+  KEY2:
+    _value: 2
+    _meta:
+      summary: Fee
+  common:
+    R: 1
+    VR: 1
+hosts:
+  - a.local
+legacy: 1
+db:
+  password: !secret qwerty
+`), 0644))
+	require.NoError(t, os.WriteFile(production, []byte(`
+fee:
+  KEY2:
+    _value: 3
+  common: !replace
+    R: 2
+hosts: !append
+  - b.local
+legacy: !delete
+absent: !delete
+`), 0644))
+
+	layers, err := GetYMLLayers([]string{base, production}, DocumentsMerge, false)
+	require.NoError(t, err)
+	config := WalkByYMLNode(layers.Node, "", false)
+	assert.Equal(t, []string{"db/password", "fee/KEY1", "fee/KEY2", "fee/common/R", "hosts"}, sortedKeys(config))
+	assert.Equal(t, "3", config["fee/KEY2"].Value)
+	assert.Equal(t, "Fee", config["fee/KEY2"].Meta.Summary)
+	assert.Equal(t, "2", config["fee/common/R"].Value)
+	assert.Equal(t, "- a.local\n- b.local", config["hosts"].Value)
+	assert.Equal(t, []string{"db/password"}, layers.SecretKeys)
+
+	assert.Equal(t, base, layers.Origin("fee/KEY1"))
+	assert.Equal(t, production, layers.Origin("fee/KEY2"))
+	assert.Equal(t, production, layers.Origin("fee/common/R"))
+	assert.Equal(t, production, layers.Origin("hosts"))
+	assert.Equal(t, base, layers.Origin("db/password"))
+	assert.Equal(t, "", layers.Origin("legacy"))
+}
+
+func TestGetYMLLayersAnchors(t *testing.T) {
+
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.yml")
+	require.NoError(t, os.WriteFile(base, []byte(`
+base: &b
+  x: 1
+svc:
+  <<: *b
+  z: 3
+old: &o
+  x: 1
+copy: *o
+`), 0644))
+
+	for name, overlay := range map[string]string{
+		"merge":   "base:\n  w: 2\n",
+		"replace": "base: !replace\n  w: 2\nold: !replace\n  w: 2\n",
+		"delete":  "base: !delete\nold: !delete\n",
+	} {
+		production := filepath.Join(dir, name+".yml")
+		require.NoError(t, os.WriteFile(production, []byte(overlay), 0644))
+
+		layers, err := GetYMLLayers([]string{base, production}, DocumentsMerge, false)
+		require.NoError(t, err, name)
+		_, err = layers.Data()
+		require.NoError(t, err, name)
+		config := WalkByYMLNode(layers.Node, "", false)
+		assert.Equal(t, "1", config["svc/x"].Value, name)
+		assert.Equal(t, "3", config["svc/z"].Value, name)
+		assert.Equal(t, "1", config["copy/x"].Value, name)
+	}
+
+	cfgFilepath, err := writeYMLConfig(`
+base: &b
+  x: 1
+svc: *b
+---
+base: &b
+  w: 2
+other: *b
+`)
+	require.NoError(t, err)
+
+	data, err := GetYMLConfig(cfgFilepath)
+	require.NoError(t, err)
+	config := WalkByYML(reflect.ValueOf(&data), "", false)
+	assert.Equal(t, []string{"base/w", "base/x", "other/w", "svc/x"}, sortedKeys(config))
+}
+
+func TestInterpolate(t *testing.T) {
+
+	t.Setenv("TEST_DB_HOST", "db.local")
+	t.Setenv("TEST_DB_PORT", "5432")
+	t.Setenv("TEST_EMPTY", "")
+
+	cfgFilepath, err := writeYMLConfig(`
+db:
+  url: postgres://${TEST_DB_HOST}:${TEST_DB_PORT:-5433}/app
+  port: !env TEST_DB_PORT
+  user: ${TEST_EMPTY:-app}
+  password: !secret ${TEST_DB_PASSWORD:-qwerty}
+  price: $$5
+  hosts:
+    - ${TEST_DB_HOST}
+  ports:
+    - ${TEST_DB_PORT}
+    - !env TEST_DB_PORT
+  empty: ${TEST_EMPTY}
+  KEY:
+    _value: !env TEST_DB_HOST
+    _meta:
+      summary: Host ${TEST_DB_HOST}
+`)
+	require.NoError(t, err)
+
+	layers, err := GetYMLLayers([]string{cfgFilepath}, DocumentsMerge, true)
+	require.NoError(t, err)
+	config := WalkByYMLNode(layers.Node, "", false)
+	assert.Equal(t, "postgres://db.local:5432/app", config["db/url"].Value)
+	assert.Equal(t, "5432", config["db/port"].Value)
+	assert.Equal(t, "app", config["db/user"].Value)
+	assert.Equal(t, "qwerty", config["db/password"].Value)
+	assert.Equal(t, "$5", config["db/price"].Value)
+	assert.Equal(t, "- db.local", config["db/hosts"].Value)
+	assert.Equal(t, "- 5432\n- 5432", config["db/ports"].Value)
+	assert.NotContains(t, config, "db/empty")
+	assert.Equal(t, "db.local", config["db/KEY"].Value)
+	assert.Equal(t, "Host db.local", config["db/KEY"].Meta.Summary)
+
+	// interpolated values are resolved like written ones by both walkers
+	data, err := layers.Data()
+	require.NoError(t, err)
+	assert.Equal(t, config, WalkByYML(reflect.ValueOf(&data), "", false))
+
+	// variables are kept as written if interpolation is disabled, values tagged as env are still replaced
+	cfgFilepath, err = writeYMLConfig(`
+tpl: 'echo ${TEST_DB_HOST}'
+price: a$$b
+missing: ${TEST_MISSING_HOST}
+port: !env TEST_DB_PORT
+`)
+	require.NoError(t, err)
+
+	layers, err = GetYMLLayers([]string{cfgFilepath}, DocumentsMerge, false)
+	require.NoError(t, err)
+	config = WalkByYMLNode(layers.Node, "", false)
+	assert.Equal(t, "echo ${TEST_DB_HOST}", config["tpl"].Value)
+	assert.Equal(t, "a$$b", config["price"].Value)
+	assert.Equal(t, "${TEST_MISSING_HOST}", config["missing"].Value)
+	assert.Equal(t, "5432", config["port"].Value)
+
+	data, err = GetYMLConfig(cfgFilepath)
+	require.NoError(t, err)
+	assert.Equal(t, config, WalkByYML(reflect.ValueOf(&data), "", false))
+
+	cfgFilepath, err = writeYMLConfig(`
+db:
+  host: ${TEST_MISSING_HOST}
+  port: !env TEST_MISSING_PORT
+  hosts:
+    - ${TEST_MISSING_HOST}
+  user: ${TEST_EMPTY}
+`)
+	require.NoError(t, err)
+
+	_, err = GetYMLLayers([]string{cfgFilepath}, DocumentsMerge, true)
+	assert.ErrorIs(t, err, ErrUnresolvedVariable)
+	assert.EqualError(t, err, "unresolved variables: TEST_MISSING_HOST at db/host, TEST_MISSING_PORT at db/port, TEST_MISSING_HOST at db/hosts")
+
+	_, err = walkYMLConfig(cfgFilepath, DocumentsMerge)
+	assert.EqualError(t, err, "unresolved variables: TEST_MISSING_PORT at db/port")
+}
+
+func TestWalkByYMLNode(t *testing.T) {
+
+	cfgFilepath, err := writeYMLConfig(`
+defaults: &defaults
+  timeout: 1.50
+  retries: 3
+scalars:
+  version: 1.10
+  float: 1e6
+  hex: 0x1F
+  big: 12345678901234567890
+  quoted: "1.10"
+  bool: yes
+  empty: ~
+  multiline: |
+    first line
+    second line
+service:
+  <<: *defaults
+  retries: 5
+  alias: *defaults
+list:
+  - 1.10
+  - "2.0"
+  - !secret 0x1F
+  - fee: 2.150
+    subject: "Simple fee"
+nulls: [a, ~, null]
+KEY:
+  _value: 1.10
+  _meta:
+    summary: 1.10
+`)
+	require.NoError(t, err)
+
+	layers, err := GetYMLLayers([]string{cfgFilepath}, DocumentsMerge, false)
+	require.NoError(t, err)
+	config := WalkByYMLNode(layers.Node, "", false)
+
+	values := map[string]string{}
+	for key, item := range config {
+		values[key] = item.Value
+	}
+	assert.Equal(t, map[string]string{
+		"defaults/timeout":      "1.50",
+		"defaults/retries":      "3",
+		"scalars/version":       "1.10",
+		"scalars/float":         "1e6",
+		"scalars/hex":           "0x1F",
+		"scalars/big":           "12345678901234567890",
+		"scalars/quoted":        "1.10",
+		"scalars/bool":          "yes",
+		"scalars/multiline":     "first line\nsecond line\n",
+		"service/timeout":       "1.50",
+		"service/retries":       "5",
+		"service/alias/timeout": "1.50",
+		"service/alias/retries": "3",
+		"list":                  "- 1.10\n- \"2.0\"\n- 0x1F\n- fee: 2.150\n  subject: \"Simple fee\"\n",
+		"nulls":                 "- a\n- ~\n- null",
+		"KEY":                   "1.10",
+	}, values)
+	assert.Equal(t, "1.10", config["KEY"].Meta.Summary)
+
+	nodes := WalkByYMLNode(layers.Node, "", true)
+	assert.Equal(t, `["alias","retries","timeout"]`, nodes["service."].Value)
+}
+
+// walkYMLConfig config of the file walked like the commands do
+func walkYMLConfig(filepath string, mode DocumentsMode) (map[string]OnlineConfItem, error) {
+	layers, err := GetYMLLayers([]string{filepath}, mode, false)
+	if err != nil {
+		return nil, err
+	}
+	return WalkByYMLNode(layers.Node, "", false), nil
+}
+
+func sortedKeys(config map[string]OnlineConfItem) []string {
+	keys := []string{}
+	for key := range config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func writeYMLConfig(content string) (string, error) {
 	f, err := ioutil.TempFile("", "testOnlineConf")
 	if err != nil {
@@ -0,0 +1,73 @@
+package parser
+
+import (
+	"strconv"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// SecretTag yaml tag of secret values, e.g. `password: !secret qwerty`
+const SecretTag = "!secret"
+
+// GetYMLSecretKeys keys of nodes tagged as secret in yml config, a tagged map is secret with all children
+func GetYMLSecretKeys(filepath string) ([]string, error) {
+	return GetYMLDocumentsSecretKeys(filepath, DocumentsMerge)
+}
+
+// GetYMLDocumentsSecretKeys keys of nodes tagged as secret in any document of yml config combined by the mode,
+// a key tagged in one of the merged documents stays secret
+func GetYMLDocumentsSecretKeys(filepath string, mode DocumentsMode) ([]string, error) {
+	layers, err := GetYMLLayers([]string{filepath}, mode, false)
+	if err != nil {
+		return nil, err
+	}
+	return layers.SecretKeys, nil
+}
+
+// collectSecretKeys add keys of secret nodes of the documents combined by the mode
+func collectSecretKeys(documents []*yamlv3.Node, mode DocumentsMode, keys map[string]bool) {
+	for i, document := range documents {
+		prefix := ""
+		if mode == DocumentsSeparate {
+			prefix = strconv.Itoa(i)
+		}
+		walkSecretNodes(document, prefix, keys)
+	}
+}
+
+// walkSecretNodes collect keys of secret nodes, returns whether the node or its descendant is secret
+func walkSecretNodes(node *yamlv3.Node, prefix string, keys map[string]bool) bool {
+	if node.Tag == SecretTag {
+		keys[prefix] = true
+		return true
+	}
+
+	found := false
+	switch node.Kind {
+	case yamlv3.DocumentNode:
+		for _, child := range node.Content {
+			found = walkSecretNodes(child, prefix, keys) || found
+		}
+	case yamlv3.MappingNode:
+		// keys are built like WalkByYMLNode does: << merge keys are expanded,
+		// value of the leaf with metadata belongs to the leaf, metadata is never secret
+		for _, pair := range mappingPairs(node) {
+			switch {
+			case prefix != "" && pair.key == ValueKey:
+				found = walkSecretNodes(pair.value, prefix, keys) || found
+			case prefix != "" && pair.key == MetaKey:
+			default:
+				found = walkSecretNodes(pair.value, joinKey(prefix, pair.key), keys) || found
+			}
+		}
+	case yamlv3.SequenceNode:
+		// the list is the single node value, so it is secret as a whole
+		for _, child := range node.Content {
+			if walkSecretNodes(child, "", map[string]bool{}) {
+				keys[prefix] = true
+				return true
+			}
+		}
+	}
+	return found
+}